@@ -14,6 +14,7 @@ import (
 	"github.com/alecthomas/kingpin/v2"
 
 	"github.com/pulumi-labs/pulumi-exporter/internal/appinfo"
+	"github.com/pulumi-labs/pulumi-exporter/internal/checkpoint"
 	"github.com/pulumi-labs/pulumi-exporter/internal/client"
 	"github.com/pulumi-labs/pulumi-exporter/internal/collector"
 	"github.com/pulumi-labs/pulumi-exporter/internal/config"
@@ -43,7 +44,7 @@ func run() error {
 		Envar("OTEL_EXPORTER_OTLP_HEADERS").
 		String()
 
-	listenAddr := app.Flag("web.listen-address", "Address to listen on for health checks.").
+	listenAddr := app.Flag("web.listen-address", "Address to listen on for health checks, /status and /metrics.").
 		Default(":8080").
 		Envar("PULUMI_EXPORTER_LISTEN_ADDRESS").
 		String()
@@ -75,27 +76,46 @@ func run() error {
 	defer cancel()
 
 	// Initialize OTel exporter.
-	otlpCfg := &exporter.OTLPConfig{
-		Endpoint: cfg.Exporters.Endpoint,
-		URLPath:  cfg.Exporters.URLPath,
-		Protocol: cfg.Exporters.Protocol,
-		Insecure: cfg.Exporters.Insecure,
-		Headers:  cfg.Exporters.Headers,
+	var otlpCfg *exporter.OTLPConfig
+	if !cfg.Exporters.Disabled {
+		otlpCfg = &exporter.OTLPConfig{
+			Endpoint: cfg.Exporters.Endpoint,
+			URLPath:  cfg.Exporters.URLPath,
+			Protocol: cfg.Exporters.Protocol,
+			Insecure: cfg.Exporters.Insecure,
+			Headers:  cfg.Exporters.Headers,
+		}
+	}
+
+	var expOpts []exporter.Option
+	if cfg.Prometheus.Enabled {
+		expOpts = append(expOpts, exporter.WithPrometheus())
 	}
 
-	exp, err := exporter.NewExporter(ctx, otlpCfg, appinfo.Version)
+	exp, err := exporter.NewExporter(ctx, otlpCfg, appinfo.Version, expOpts...)
 	if err != nil {
 		return fmt.Errorf("failed to create exporter: %w", err)
 	}
 
 	// Create Pulumi API client backed by the generated OpenAPI client.
-	apiClient, err := client.NewClient(cfg.Pulumi.APIURL, cfg.Pulumi.AccessToken)
+	apiClient, err := client.NewClient(cfg.Pulumi.APIURL, cfg.Pulumi.AccessToken,
+		client.WithRetries(cfg.Pulumi.MaxRetries),
+		client.WithRetryBudget(cfg.Pulumi.RetryBudget),
+		client.WithRateLimit(cfg.Pulumi.RateLimit, cfg.Pulumi.RateBurst),
+		client.WithMeter(exp.Meter()),
+	)
 	if err != nil {
 		return fmt.Errorf("failed to create API client: %w", err)
 	}
 
+	// Create the checkpoint store that persists last seen update versions.
+	checkpoints, err := checkpoint.NewStore(cfg.Checkpoint.Store, cfg.Checkpoint.Path)
+	if err != nil {
+		return fmt.Errorf("failed to create checkpoint store: %w", err)
+	}
+
 	// Create collector.
-	coll, err := collector.NewCollector(apiClient, cfg, exp.Meter(), logger)
+	coll, err := collector.NewCollector(apiClient, checkpoints, cfg, exp.Meter(), logger)
 	if err != nil {
 		return fmt.Errorf("failed to create collector: %w", err)
 	}
@@ -108,19 +128,9 @@ func run() error {
 	}()
 
 	// Start health check server.
-	mux := http.NewServeMux()
-	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		_, _ = fmt.Fprint(w, "ok")
-	})
-	mux.HandleFunc("/readyz", func(w http.ResponseWriter, _ *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		_, _ = fmt.Fprint(w, "ok")
-	})
-
 	srv := &http.Server{
 		Addr:              *listenAddr,
-		Handler:           mux,
+		Handler:           newWebHandler(coll, exp.Handler()),
 		ReadHeaderTimeout: 5 * time.Second,
 	}
 
@@ -0,0 +1,40 @@
+package pulumiexporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/pulumi-labs/pulumi-exporter/internal/collector"
+)
+
+// newWebHandler returns the handler for the health check and status endpoints,
+// and for /metrics when metrics is not nil.
+func newWebHandler(coll *collector.Collector, metrics http.Handler) http.Handler {
+	mux := http.NewServeMux()
+	if metrics != nil {
+		mux.Handle("/metrics", metrics)
+	}
+	mux.HandleFunc("/healthz", probeHandler(coll.Healthy))
+	mux.HandleFunc("/readyz", probeHandler(coll.Ready))
+	mux.HandleFunc("/status", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(coll.Status())
+	})
+	return mux
+}
+
+// probeHandler answers 200 "ok" while check returns true and 503 otherwise.
+func probeHandler(check func() bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		if !check() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = fmt.Fprint(w, "not ok")
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = fmt.Fprint(w, "ok")
+	}
+}
@@ -0,0 +1,100 @@
+// Package checkpoint persists the per-stack update versions and per-org
+// deployments the collector has already counted, so that restarts do not
+// re-add old updates and deployments to counters.
+package checkpoint
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// Supported checkpoint store backends.
+const (
+	StoreMemory = "memory"
+	StoreFile   = "file"
+)
+
+// State is the collection progress kept in a Store.
+type State struct {
+	// Stacks holds the last seen update version of each stack, keyed by
+	// "org/project/stack".
+	Stacks map[string]int `json:"stacks"`
+	// Pending holds the versions of the updates of each stack that were
+	// still in progress and are counted once they finish.
+	Pending map[string][]int `json:"pending,omitempty"`
+	// Deployments holds the IDs of the finished deployments already
+	// counted for each org, among its most recently fetched deployments.
+	Deployments map[string][]string `json:"deployments,omitempty"`
+}
+
+// NewState creates an empty State.
+func NewState() State {
+	return State{
+		Stacks:      make(map[string]int),
+		Pending:     make(map[string][]int),
+		Deployments: make(map[string][]string),
+	}
+}
+
+// clone returns a copy of s that shares no maps or slices with it.
+func (s State) clone() State {
+	c := NewState()
+	maps.Copy(c.Stacks, s.Stacks)
+	for key, versions := range s.Pending {
+		c.Pending[key] = slices.Clone(versions)
+	}
+	for org, ids := range s.Deployments {
+		c.Deployments[org] = slices.Clone(ids)
+	}
+	return c
+}
+
+// Store loads and saves the collection progress.
+type Store interface {
+	Load(ctx context.Context) (State, error)
+	Save(ctx context.Context, state State) error
+}
+
+// NewStore creates a Store for the named backend.
+func NewStore(backend, path string) (Store, error) {
+	switch backend {
+	case StoreMemory, "":
+		return NewMemoryStore(), nil
+	case StoreFile:
+		if path == "" {
+			return nil, fmt.Errorf("checkpoint store %q requires a path", backend)
+		}
+		return NewFileStore(path), nil
+	default:
+		return nil, fmt.Errorf("unsupported checkpoint store: %q", backend)
+	}
+}
+
+// MemoryStore keeps checkpoints in memory only. Checkpoints are lost on restart.
+type MemoryStore struct {
+	mu    sync.Mutex
+	state State
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{state: NewState()}
+}
+
+// Load returns a copy of the stored checkpoints.
+func (s *MemoryStore) Load(_ context.Context) (State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state.clone(), nil
+}
+
+// Save replaces the stored checkpoints with a copy of state.
+func (s *MemoryStore) Save(_ context.Context, state State) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = state.clone()
+	return nil
+}
@@ -0,0 +1,110 @@
+package checkpoint
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testStackKey = "test-org/my-project/dev"
+
+func TestFileStoreRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := NewFileStore(filepath.Join(t.TempDir(), "checkpoint.json"))
+
+	// A missing file loads as an empty checkpoint.
+	state, err := store.Load(ctx)
+	if err != nil {
+		t.Fatalf("Load() on missing file: %v", err)
+	}
+	if len(state.Stacks) != 0 || len(state.Deployments) != 0 {
+		t.Errorf("expected empty checkpoint, got %+v", state)
+	}
+
+	state.Stacks[testStackKey] = 7
+	state.Pending[testStackKey] = []int{6}
+	state.Deployments["test-org"] = []string{"dep-1", "dep-2"}
+	if err := store.Save(ctx, state); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	// A fresh store over the same path sees the saved state.
+	reloaded, err := NewFileStore(store.path).Load(ctx)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if reloaded.Stacks[testStackKey] != 7 {
+		t.Errorf("expected version 7 for %s, got %d", testStackKey, reloaded.Stacks[testStackKey])
+	}
+	if got := reloaded.Pending[testStackKey]; len(got) != 1 || got[0] != 6 {
+		t.Errorf("expected pending [6] for %s, got %v", testStackKey, got)
+	}
+	if got := reloaded.Deployments["test-org"]; len(got) != 2 || got[0] != "dep-1" || got[1] != "dep-2" {
+		t.Errorf("expected deployments [dep-1 dep-2], got %v", got)
+	}
+}
+
+func TestFileStoreWithoutDeployments(t *testing.T) {
+	t.Parallel()
+
+	// Files written before deployments were checkpointed still load.
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	if err := os.WriteFile(path, []byte(`{"version":1,"stacks":{"`+testStackKey+`":3}}`), 0o600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	state, err := NewFileStore(path).Load(context.Background())
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if state.Stacks[testStackKey] != 3 || state.Deployments == nil || len(state.Deployments) != 0 {
+		t.Errorf("expected version 3 and no deployments, got %+v", state)
+	}
+}
+
+func TestFileStoreCorrupt(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	if err := os.WriteFile(path, []byte("not json"), 0o600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	if _, err := NewFileStore(path).Load(context.Background()); err == nil {
+		t.Fatal("expected error for corrupt checkpoint file, got nil")
+	}
+}
+
+func TestNewStore(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		backend string
+		path    string
+		wantErr bool
+	}{
+		{"default", "", "", false},
+		{"memory", StoreMemory, "", false},
+		{"file", StoreFile, "/tmp/checkpoint.json", false},
+		{"file without path", StoreFile, "", true},
+		{"unknown", "etcd", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewStore(tt.backend, tt.path)
+			if tt.wantErr && err == nil {
+				t.Errorf("expected error for backend %q, got nil", tt.backend)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("unexpected error for backend %q: %v", tt.backend, err)
+			}
+		})
+	}
+}
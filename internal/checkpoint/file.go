@@ -0,0 +1,94 @@
+package checkpoint
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// fileFormatVersion is bumped when the on-disk layout changes incompatibly.
+// Fields added to State are optional, so files without them still load.
+const fileFormatVersion = 1
+
+type fileContents struct {
+	Version int `json:"version"`
+	State
+}
+
+// FileStore persists checkpoints as a JSON document on the local filesystem.
+// Writes go to a temporary file that is renamed into place, so a crash never
+// leaves a partially written checkpoint behind.
+type FileStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileStore creates a FileStore backed by the file at path.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Load reads the checkpoint file. A missing file yields an empty result.
+func (s *FileStore) Load(_ context.Context) (State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return NewState(), nil
+	}
+	if err != nil {
+		return State{}, fmt.Errorf("reading checkpoint file: %w", err)
+	}
+
+	contents := fileContents{State: NewState()}
+	if err := json.Unmarshal(data, &contents); err != nil {
+		return State{}, fmt.Errorf("parsing checkpoint file: %w", err)
+	}
+	if contents.Version != fileFormatVersion {
+		return State{}, fmt.Errorf("unsupported checkpoint file version %d", contents.Version)
+	}
+
+	return contents.clone(), nil
+}
+
+// Save atomically writes state to the checkpoint file.
+func (s *FileStore) Save(_ context.Context, state State) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.Marshal(fileContents{Version: fileFormatVersion, State: state})
+	if err != nil {
+		return fmt.Errorf("encoding checkpoint: %w", err)
+	}
+
+	dir := filepath.Dir(s.path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(s.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("creating checkpoint temp file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("writing checkpoint: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("syncing checkpoint: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("closing checkpoint: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("replacing checkpoint file: %w", err)
+	}
+
+	return nil
+}
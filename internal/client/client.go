@@ -9,6 +9,8 @@ import (
 	"strings"
 	"time"
 
+	"go.opentelemetry.io/otel/metric"
+
 	"github.com/pulumi-labs/pulumi-exporter/internal/pulumiapi"
 )
 
@@ -17,8 +19,49 @@ type Client struct {
 	gen *pulumiapi.ClientWithResponses
 }
 
+// Option configures optional Client behavior.
+type Option func(*options)
+
+type options struct {
+	maxRetries  int
+	retryBudget int
+	rateLimit   float64
+	rateBurst   int
+	meter       metric.Meter
+}
+
+// WithMeter records the exporter's own API request metrics on meter.
+func WithMeter(meter metric.Meter) Option {
+	return func(o *options) { o.meter = meter }
+}
+
+// WithRetries sets the maximum number of retries for a transient failure.
+func WithRetries(maxRetries int) Option {
+	return func(o *options) { o.maxRetries = maxRetries }
+}
+
+// WithRetryBudget limits retries per endpoint to perMinute retries per minute.
+// Zero disables the budget.
+func WithRetryBudget(perMinute int) Option {
+	return func(o *options) { o.retryBudget = perMinute }
+}
+
+// WithRateLimit limits all API requests to requestsPerSecond with the given
+// burst. Zero disables rate limiting.
+func WithRateLimit(requestsPerSecond float64, burst int) Option {
+	return func(o *options) {
+		o.rateLimit = requestsPerSecond
+		o.rateBurst = burst
+	}
+}
+
 // NewClient creates a new Pulumi Cloud API client.
-func NewClient(baseURL, token string) (*Client, error) {
+func NewClient(baseURL, token string, opts ...Option) (*Client, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	authProvider := func(_ context.Context, req *http.Request) error {
 		req.Header.Set("Authorization", "token "+token)
 		return nil
@@ -29,7 +72,19 @@ func NewClient(baseURL, token string) (*Client, error) {
 		baseURL += "/"
 	}
 
-	httpClient := &http.Client{Timeout: 30 * time.Second}
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.ResponseHeaderTimeout = responseHeaderTimeout
+
+	var transport http.RoundTripper = base
+	if o.meter != nil {
+		instrumented, err := newInstrumentedTransport(base, o.meter)
+		if err != nil {
+			return nil, fmt.Errorf("creating client instruments: %w", err)
+		}
+		transport = instrumented
+	}
+
+	httpClient := &http.Client{Transport: newRetryTransport(transport, &o)}
 	gen, err := pulumiapi.NewClientWithResponses(baseURL,
 		pulumiapi.WithHTTPClient(httpClient),
 		pulumiapi.WithRequestEditorFn(authProvider),
@@ -43,6 +98,7 @@ func NewClient(baseURL, token string) (*Client, error) {
 
 // ListStacks returns all stacks accessible to the authenticated user, handling pagination.
 func (c *Client) ListStacks(ctx context.Context) (*ListStacksResponse, error) {
+	ctx = withEndpoint(ctx, "ListStacks")
 	var allStacks []StackSummary
 	var contToken *string
 
@@ -54,17 +110,21 @@ func (c *Client) ListStacks(ctx context.Context) (*ListStacksResponse, error) {
 			return nil, fmt.Errorf("listing stacks: %w", err)
 		}
 		if resp.StatusCode() != http.StatusOK || resp.JSON200 == nil {
-			return nil, fmt.Errorf("listing stacks: unexpected status %d", resp.StatusCode())
+			return nil, fmt.Errorf("listing stacks: %w", newAPIError(ctx, resp.HTTPResponse, resp.Body))
 		}
 
 		for _, s := range resp.JSON200.Stacks {
-			allStacks = append(allStacks, StackSummary{
-				OrgName:       s.OrgName,
-				ProjectName:   s.ProjectName,
-				StackName:     s.StackName,
-				LastUpdate:    derefInt64(s.LastUpdate),
-				ResourceCount: int(derefInt64(s.ResourceCount)),
-			})
+			summary := StackSummary{
+				OrgName:     s.OrgName,
+				ProjectName: s.ProjectName,
+				StackName:   s.StackName,
+				LastUpdate:  derefInt64(s.LastUpdate),
+			}
+			if s.ResourceCount != nil {
+				count := int(*s.ResourceCount)
+				summary.ResourceCount = &count
+			}
+			allStacks = append(allStacks, summary)
 		}
 
 		if resp.JSON200.ContinuationToken == nil || *resp.JSON200.ContinuationToken == "" {
@@ -78,12 +138,13 @@ func (c *Client) ListStacks(ctx context.Context) (*ListStacksResponse, error) {
 
 // GetResourceCount returns the resource count for a specific stack.
 func (c *Client) GetResourceCount(ctx context.Context, org, project, stack string) (*ResourceCountResponse, error) {
+	ctx = withEndpoint(ctx, "GetResourceCount")
 	resp, err := c.gen.GetStackResourceCountWithResponse(ctx, org, project, stack)
 	if err != nil {
 		return nil, fmt.Errorf("getting resource count: %w", err)
 	}
 	if resp.StatusCode() != http.StatusOK || resp.JSON200 == nil {
-		return nil, fmt.Errorf("getting resource count: unexpected status %d", resp.StatusCode())
+		return nil, fmt.Errorf("getting resource count: %w", newAPIError(ctx, resp.HTTPResponse, resp.Body))
 	}
 
 	return &ResourceCountResponse{
@@ -96,6 +157,7 @@ func (c *Client) GetResourceCount(ctx context.Context, org, project, stack strin
 // The Pulumi OpenAPI spec returns an untyped response for this endpoint,
 // so we parse the raw JSON body from the generated client's response.
 func (c *Client) ListUpdates(ctx context.Context, org, project, stack string, page, pageSize int) (*ListUpdatesResponse, error) {
+	ctx = withEndpoint(ctx, "ListUpdates")
 	p := int64(page)
 	ps := int64(pageSize)
 	resp, err := c.gen.GetStackUpdatesWithResponse(ctx, org, project, stack, &pulumiapi.GetStackUpdatesParams{
@@ -106,7 +168,7 @@ func (c *Client) ListUpdates(ctx context.Context, org, project, stack string, pa
 		return nil, fmt.Errorf("listing updates: %w", err)
 	}
 	if resp.StatusCode() != http.StatusOK {
-		return nil, fmt.Errorf("listing updates: unexpected status %d", resp.StatusCode())
+		return nil, fmt.Errorf("listing updates: %w", newAPIError(ctx, resp.HTTPResponse, resp.Body))
 	}
 
 	var result ListUpdatesResponse
@@ -117,30 +179,59 @@ func (c *Client) ListUpdates(ctx context.Context, org, project, stack string, pa
 	return &result, nil
 }
 
-// ListOrgDeployments returns the deployments for an organization.
-func (c *Client) ListOrgDeployments(ctx context.Context, org string) (*ListDeploymentsResponse, error) {
-	resp, err := c.gen.ListOrgDeploymentsWithResponse(ctx, org, nil)
+// ListOrgDeployments returns the deployments of an organization, newest
+// first, handling pagination up to maxPages pages of 100 deployments.
+func (c *Client) ListOrgDeployments(ctx context.Context, org string, maxPages int) (*ListDeploymentsResponse, error) {
+	ctx = withEndpoint(ctx, "ListOrgDeployments")
+	all := &ListDeploymentsResponse{}
+	pageSize := int64(100)
+
+	for page := int64(1); page <= int64(maxPages); page++ {
+		resp, err := c.gen.ListOrgDeploymentsWithResponse(ctx, org, &pulumiapi.ListOrgDeploymentsParams{
+			Page:     &page,
+			PageSize: &pageSize,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("listing org deployments: %w", err)
+		}
+		if resp.StatusCode() != http.StatusOK || resp.JSON200 == nil {
+			return nil, fmt.Errorf("listing org deployments: %w", newAPIError(ctx, resp.HTTPResponse, resp.Body))
+		}
+
+		deployments := convertDeployments(resp.JSON200)
+		all.Deployments = append(all.Deployments, deployments.Deployments...)
+		all.Total = deployments.Total
+
+		if len(deployments.Deployments) < int(pageSize) || len(all.Deployments) >= all.Total {
+			break
+		}
+	}
+
+	return all, nil
+}
+
+// ListStackDeployments returns one page of the deployments of a stack, newest first.
+func (c *Client) ListStackDeployments(ctx context.Context, org, project, stack string, page, pageSize int) (*ListDeploymentsResponse, error) {
+	ctx = withEndpoint(ctx, "ListStackDeployments")
+	p := int64(page)
+	ps := int64(pageSize)
+	resp, err := c.gen.ListStackDeploymentsHandlerV2WithResponse(ctx, org, project, stack, &pulumiapi.ListStackDeploymentsHandlerV2Params{
+		Page:     &p,
+		PageSize: &ps,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("listing org deployments: %w", err)
+		return nil, fmt.Errorf("listing stack deployments: %w", err)
 	}
 	if resp.StatusCode() != http.StatusOK || resp.JSON200 == nil {
-		return nil, fmt.Errorf("listing org deployments: unexpected status %d", resp.StatusCode())
-	}
-
-	deployments := make([]DeploymentInfo, 0, len(resp.JSON200.Deployments))
-	for _, d := range resp.JSON200.Deployments {
-		deployments = append(deployments, DeploymentInfo{
-			ID:      d.Id,
-			Status:  string(d.Status),
-			Created: d.Created,
-		})
+		return nil, fmt.Errorf("listing stack deployments: %w", newAPIError(ctx, resp.HTTPResponse, resp.Body))
 	}
 
-	return &ListDeploymentsResponse{Deployments: deployments}, nil
+	return convertDeployments(resp.JSON200), nil
 }
 
 // ListMembers returns the members of an organization, handling pagination.
 func (c *Client) ListMembers(ctx context.Context, org string) (*ListMembersResponse, error) {
+	ctx = withEndpoint(ctx, "ListMembers")
 	var allMembers []MemberInfo
 	var contToken *string
 
@@ -151,7 +242,7 @@ func (c *Client) ListMembers(ctx context.Context, org string) (*ListMembersRespo
 			return nil, fmt.Errorf("listing members: %w", err)
 		}
 		if resp.StatusCode() != http.StatusOK || resp.JSON200 == nil {
-			return nil, fmt.Errorf("listing members: unexpected status %d", resp.StatusCode())
+			return nil, fmt.Errorf("listing members: %w", newAPIError(ctx, resp.HTTPResponse, resp.Body))
 		}
 
 		for _, m := range resp.JSON200.Members {
@@ -172,12 +263,13 @@ func (c *Client) ListMembers(ctx context.Context, org string) (*ListMembersRespo
 
 // ListTeams returns the teams of an organization.
 func (c *Client) ListTeams(ctx context.Context, org string) (*ListTeamsResponse, error) {
+	ctx = withEndpoint(ctx, "ListTeams")
 	resp, err := c.gen.ListTeamsWithResponse(ctx, org)
 	if err != nil {
 		return nil, fmt.Errorf("listing teams: %w", err)
 	}
 	if resp.StatusCode() != http.StatusOK || resp.JSON200 == nil {
-		return nil, fmt.Errorf("listing teams: unexpected status %d", resp.StatusCode())
+		return nil, fmt.Errorf("listing teams: %w", newAPIError(ctx, resp.HTTPResponse, resp.Body))
 	}
 
 	teams := make([]TeamInfo, 0, len(resp.JSON200.Teams))
@@ -194,6 +286,7 @@ func (c *Client) ListTeams(ctx context.Context, org string) (*ListTeamsResponse,
 
 // ListEnvironments returns the ESC environments of an organization, handling pagination.
 func (c *Client) ListEnvironments(ctx context.Context, org string) (*ListEnvironmentsResponse, error) {
+	ctx = withEndpoint(ctx, "ListEnvironments")
 	var allEnvs []EnvironmentInfo
 	var contToken *string
 
@@ -204,7 +297,7 @@ func (c *Client) ListEnvironments(ctx context.Context, org string) (*ListEnviron
 			return nil, fmt.Errorf("listing environments: %w", err)
 		}
 		if resp.StatusCode() != http.StatusOK || resp.JSON200 == nil {
-			return nil, fmt.Errorf("listing environments: unexpected status %d", resp.StatusCode())
+			return nil, fmt.Errorf("listing environments: %w", newAPIError(ctx, resp.HTTPResponse, resp.Body))
 		}
 
 		for _, e := range resp.JSON200.Environments {
@@ -226,12 +319,13 @@ func (c *Client) ListEnvironments(ctx context.Context, org string) (*ListEnviron
 
 // ListPolicyGroups returns the policy groups of an organization.
 func (c *Client) ListPolicyGroups(ctx context.Context, org string) (*ListPolicyGroupsResponse, error) {
+	ctx = withEndpoint(ctx, "ListPolicyGroups")
 	resp, err := c.gen.ListPolicyGroupsWithResponse(ctx, org)
 	if err != nil {
 		return nil, fmt.Errorf("listing policy groups: %w", err)
 	}
 	if resp.StatusCode() != http.StatusOK || resp.JSON200 == nil {
-		return nil, fmt.Errorf("listing policy groups: unexpected status %d", resp.StatusCode())
+		return nil, fmt.Errorf("listing policy groups: %w", newAPIError(ctx, resp.HTTPResponse, resp.Body))
 	}
 
 	groups := make([]PolicyGroupInfo, 0, len(resp.JSON200.PolicyGroups))
@@ -247,14 +341,49 @@ func (c *Client) ListPolicyGroups(ctx context.Context, org string) (*ListPolicyG
 	return &ListPolicyGroupsResponse{PolicyGroups: groups}, nil
 }
 
+// GetPolicyGroup returns a policy group of an organization with its stacks.
+func (c *Client) GetPolicyGroup(ctx context.Context, org, group string) (*PolicyGroupResponse, error) {
+	ctx = withEndpoint(ctx, "GetPolicyGroup")
+	resp, err := c.gen.GetPolicyGroupWithResponse(ctx, org, group)
+	if err != nil {
+		return nil, fmt.Errorf("getting policy group: %w", err)
+	}
+	if resp.StatusCode() != http.StatusOK || resp.JSON200 == nil {
+		return nil, fmt.Errorf("getting policy group: %w", newAPIError(ctx, resp.HTTPResponse, resp.Body))
+	}
+
+	stacks := make([]PolicyGroupStack, 0, len(resp.JSON200.Stacks))
+	for _, s := range resp.JSON200.Stacks {
+		stacks = append(stacks, PolicyGroupStack{ProjectName: s.RoutingProject, StackName: s.Name})
+	}
+
+	packs := make([]AppliedPolicyPack, 0, len(resp.JSON200.AppliedPolicyPacks))
+	for _, p := range resp.JSON200.AppliedPolicyPacks {
+		packs = append(packs, AppliedPolicyPack{
+			Name:        p.Name,
+			DisplayName: p.DisplayName,
+			Version:     p.Version,
+			VersionTag:  p.VersionTag,
+		})
+	}
+
+	return &PolicyGroupResponse{
+		Name:               resp.JSON200.Name,
+		IsOrgDefault:       resp.JSON200.IsOrgDefault,
+		Stacks:             stacks,
+		AppliedPolicyPacks: packs,
+	}, nil
+}
+
 // ListPolicyPacks returns the policy packs of an organization.
 func (c *Client) ListPolicyPacks(ctx context.Context, org string) (*ListPolicyPacksResponse, error) {
+	ctx = withEndpoint(ctx, "ListPolicyPacks")
 	resp, err := c.gen.ListPolicyPacksOrgsWithResponse(ctx, org, nil)
 	if err != nil {
 		return nil, fmt.Errorf("listing policy packs: %w", err)
 	}
 	if resp.StatusCode() != http.StatusOK || resp.JSON200 == nil {
-		return nil, fmt.Errorf("listing policy packs: unexpected status %d", resp.StatusCode())
+		return nil, fmt.Errorf("listing policy packs: %w", newAPIError(ctx, resp.HTTPResponse, resp.Body))
 	}
 
 	packs := make([]PolicyPackInfo, 0, len(resp.JSON200.PolicyPacks))
@@ -262,46 +391,76 @@ func (c *Client) ListPolicyPacks(ctx context.Context, org string) (*ListPolicyPa
 		packs = append(packs, PolicyPackInfo{
 			Name:        p.Name,
 			DisplayName: p.DisplayName,
+			Versions:    p.Versions,
+			VersionTags: p.VersionTags,
 		})
 	}
 
 	return &ListPolicyPacksResponse{PolicyPacks: packs}, nil
 }
 
-// ListPolicyViolations returns the policy violations for an organization.
-func (c *Client) ListPolicyViolations(ctx context.Context, org string) (*ListPolicyViolationsResponse, error) {
-	resp, err := c.gen.ListPolicyViolationsV2WithResponse(ctx, org)
-	if err != nil {
-		return nil, fmt.Errorf("listing policy violations: %w", err)
-	}
-	if resp.StatusCode() != http.StatusOK || resp.JSON200 == nil {
-		return nil, fmt.Errorf("listing policy violations: unexpected status %d", resp.StatusCode())
-	}
-
-	violations := make([]PolicyViolation, 0, len(resp.JSON200.PolicyViolations))
-	for _, v := range resp.JSON200.PolicyViolations {
-		violations = append(violations, PolicyViolation{
-			ID:          v.Id,
-			ProjectName: v.ProjectName,
-			StackName:   derefStr(v.StackName),
-			PolicyPack:  v.PolicyPack,
-			PolicyName:  v.PolicyName,
-			Level:       v.Level,
-			Kind:        string(v.Kind),
-		})
+// ListPolicyViolations returns the policy violations of an organization,
+// handling pagination up to maxPages pages, and reports whether the page cap
+// truncated them.
+func (c *Client) ListPolicyViolations(ctx context.Context, org string, maxPages int) (*ListPolicyViolationsResponse, bool, error) {
+	ctx = withEndpoint(ctx, "ListPolicyViolations")
+	var allViolations []PolicyViolation
+	var contToken string
+
+	for range maxPages {
+		// The generated client has no parameters for this endpoint, so the
+		// continuation token is added to the query directly.
+		var editors []pulumiapi.RequestEditorFn
+		if contToken != "" {
+			editors = append(editors, withQueryParam("continuationToken", contToken))
+		}
+		resp, err := c.gen.ListPolicyViolationsV2WithResponse(ctx, org, editors...)
+		if err != nil {
+			return nil, false, fmt.Errorf("listing policy violations: %w", err)
+		}
+		if resp.StatusCode() != http.StatusOK || resp.JSON200 == nil {
+			return nil, false, fmt.Errorf("listing policy violations: %w", newAPIError(ctx, resp.HTTPResponse, resp.Body))
+		}
+
+		for _, v := range resp.JSON200.PolicyViolations {
+			allViolations = append(allViolations, PolicyViolation{
+				ID:              v.Id,
+				ProjectName:     v.ProjectName,
+				StackName:       derefStr(v.StackName),
+				StackVersion:    derefInt64(v.StackVersion),
+				AccountName:     derefStr(v.AccountName),
+				PolicyPack:      v.PolicyPack,
+				PolicyPackTag:   v.PolicyPackTag,
+				PolicyName:      v.PolicyName,
+				ResourceURN:     v.ResourceURN,
+				ResourceType:    v.ResourceType,
+				ResourceName:    v.ResourceName,
+				ResourceVersion: derefInt64(v.ResourceVersion),
+				Message:         v.Message,
+				ObservedAt:      v.ObservedAt,
+				Level:           v.Level,
+				Kind:            string(v.Kind),
+			})
+		}
+
+		if resp.JSON200.ContinuationToken == nil || *resp.JSON200.ContinuationToken == "" {
+			return &ListPolicyViolationsResponse{PolicyViolations: allViolations}, false, nil
+		}
+		contToken = *resp.JSON200.ContinuationToken
 	}
 
-	return &ListPolicyViolationsResponse{PolicyViolations: violations}, nil
+	return &ListPolicyViolationsResponse{PolicyViolations: allViolations, ContinuationToken: contToken}, true, nil
 }
 
 // GetPolicyResultsMetadata returns policy compliance metadata for an organization.
 func (c *Client) GetPolicyResultsMetadata(ctx context.Context, org string) (*PolicyResultsMetadataResponse, error) {
+	ctx = withEndpoint(ctx, "GetPolicyResultsMetadata")
 	resp, err := c.gen.GetPolicyResultsMetadataWithResponse(ctx, org)
 	if err != nil {
 		return nil, fmt.Errorf("getting policy results metadata: %w", err)
 	}
 	if resp.StatusCode() != http.StatusOK || resp.JSON200 == nil {
-		return nil, fmt.Errorf("getting policy results metadata: unexpected status %d", resp.StatusCode())
+		return nil, fmt.Errorf("getting policy results metadata: %w", newAPIError(ctx, resp.HTTPResponse, resp.Body))
 	}
 
 	return &PolicyResultsMetadataResponse{
@@ -314,6 +473,7 @@ func (c *Client) GetPolicyResultsMetadata(ctx context.Context, org string) (*Pol
 
 // ListNeoTasks returns all Neo AI tasks for an organization, handling pagination.
 func (c *Client) ListNeoTasks(ctx context.Context, org string) (*ListNeoTasksResponse, error) {
+	ctx = withEndpoint(ctx, "ListNeoTasks")
 	var allTasks []NeoTask
 	var contToken *string
 	pageSize := int64(100)
@@ -327,7 +487,7 @@ func (c *Client) ListNeoTasks(ctx context.Context, org string) (*ListNeoTasksRes
 			return nil, fmt.Errorf("listing neo tasks: %w", err)
 		}
 		if resp.StatusCode() != http.StatusOK || resp.JSON200 == nil {
-			return nil, fmt.Errorf("listing neo tasks: unexpected status %d", resp.StatusCode())
+			return nil, fmt.Errorf("listing neo tasks: %w", newAPIError(ctx, resp.HTTPResponse, resp.Body))
 		}
 
 		for _, t := range resp.JSON200.Tasks {
@@ -351,6 +511,7 @@ func (c *Client) ListNeoTasks(ctx context.Context, org string) (*ListNeoTasksRes
 
 // GetOrgNeoTokenBudget returns the Pulumi Neo token budget for an organization.
 func (c *Client) GetOrgNeoTokenBudget(ctx context.Context, org string) (*NeoTokenBudgetResponse, error) {
+	ctx = withEndpoint(ctx, "GetOrgNeoTokenBudget")
 	resp, err := c.gen.GetOrgNeoTokenBudgetWithResponse(ctx, org)
 	if err != nil {
 		return nil, fmt.Errorf("getting neo token budget: %w", err)
@@ -361,7 +522,7 @@ func (c *Client) GetOrgNeoTokenBudget(ctx context.Context, org string) (*NeoToke
 		return nil, nil
 	}
 	if resp.StatusCode() != http.StatusOK || resp.JSON200 == nil {
-		return nil, fmt.Errorf("getting neo token budget: unexpected status %d", resp.StatusCode())
+		return nil, fmt.Errorf("getting neo token budget: %w", newAPIError(ctx, resp.HTTPResponse, resp.Body))
 	}
 
 	return &NeoTokenBudgetResponse{
@@ -374,6 +535,57 @@ func (c *Client) GetOrgNeoTokenBudget(ctx context.Context, org string) (*NeoToke
 	}, nil
 }
 
+// convertDeployments converts a page of deployment snapshots.
+func convertDeployments(page *pulumiapi.ListDeploymentResponseV2) *ListDeploymentsResponse {
+	deployments := make([]DeploymentInfo, 0, len(page.Deployments))
+	for _, d := range page.Deployments {
+		info := DeploymentInfo{
+			ID:          d.Id,
+			Status:      string(d.Status),
+			Created:     d.Created,
+			Modified:    d.Modified,
+			ProjectName: derefStr(d.ProjectName),
+			StackName:   derefStr(d.StackName),
+			Version:     int(d.Version),
+			Operation:   string(d.PulumiOperation),
+			Initiator:   derefStr(d.Initiator),
+			Paused:      d.Paused != nil && *d.Paused,
+		}
+		if d.AgentPool != nil {
+			info.AgentPool = d.AgentPool.Name
+		}
+		for _, j := range d.Jobs {
+			job := DeploymentJob{
+				Status:      string(j.Status),
+				Started:     derefTime(j.Started),
+				LastUpdated: derefTime(j.LastUpdated),
+			}
+			for _, s := range j.Steps {
+				job.Steps = append(job.Steps, DeploymentStep{
+					Name:        s.Name,
+					Status:      string(s.Status),
+					Started:     derefTime(s.Started),
+					LastUpdated: derefTime(s.LastUpdated),
+				})
+			}
+			info.Jobs = append(info.Jobs, job)
+		}
+		deployments = append(deployments, info)
+	}
+
+	return &ListDeploymentsResponse{Deployments: deployments, Total: int(page.Total)}
+}
+
+// withQueryParam returns a request editor that sets the query parameter key.
+func withQueryParam(key, value string) pulumiapi.RequestEditorFn {
+	return func(_ context.Context, req *http.Request) error {
+		q := req.URL.Query()
+		q.Set(key, value)
+		req.URL.RawQuery = q.Encode()
+		return nil
+	}
+}
+
 func derefStr(s *string) string {
 	if s == nil {
 		return ""
@@ -387,3 +599,10 @@ func derefInt64(i *int64) int64 {
 	}
 	return *i
 }
+
+func derefTime(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
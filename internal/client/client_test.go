@@ -0,0 +1,98 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestListPolicyViolationsPages(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("continuationToken") == "next" {
+			_, _ = w.Write([]byte(`{"policyViolations":[{"id":"2","projectName":"p","policyPack":"aws","policyName":"tags",` +
+				`"resourceURN":"urn:pulumi:dev::p::aws:s3/bucket:Bucket::b","resourceType":"aws:s3/bucket:Bucket","resourceName":"b",` +
+				`"message":"missing tags","observedAt":"2024-01-01T00:00:00Z","level":"advisory","kind":"audit","stackVersion":7}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"policyViolations":[{"id":"1","projectName":"p","policyPack":"aws","policyName":"tags",` +
+			`"observedAt":"2024-01-01T00:00:00Z","level":"advisory","kind":"audit"}],"continuationToken":"next"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	c, err := NewClient(srv.URL, "pul-token")
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	ctx := context.Background()
+
+	resp, truncated, err := c.ListPolicyViolations(ctx, "org", 5)
+	if err != nil {
+		t.Fatalf("ListPolicyViolations() error: %v", err)
+	}
+	if truncated || len(resp.PolicyViolations) != 2 {
+		t.Fatalf("expected 2 violations, not truncated, got %d, truncated=%v", len(resp.PolicyViolations), truncated)
+	}
+	if v := resp.PolicyViolations[1]; v.ID != "2" || v.ResourceName != "b" || v.Message != "missing tags" || v.StackVersion != 7 {
+		t.Errorf("violation not fully decoded: %+v", v)
+	}
+
+	// The page cap stops paging before the last page.
+	resp, truncated, err = c.ListPolicyViolations(ctx, "org", 1)
+	if err != nil {
+		t.Fatalf("ListPolicyViolations() error: %v", err)
+	}
+	if !truncated || len(resp.PolicyViolations) != 1 {
+		t.Errorf("expected 1 violation, truncated, got %d, truncated=%v", len(resp.PolicyViolations), truncated)
+	}
+}
+
+func TestListOrgDeploymentsPages(t *testing.T) {
+	t.Parallel()
+
+	const total = 150
+	var requests atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		pageSize, _ := strconv.Atoi(r.URL.Query().Get("pageSize"))
+		var deployments []string
+		for i := (page - 1) * pageSize; i < min(page*pageSize, total); i++ {
+			deployments = append(deployments, fmt.Sprintf(`{"id":"%d","status":"succeeded"}`, i))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"deployments":[%s],"itemsPerPage":%d,"total":%d}`, strings.Join(deployments, ","), pageSize, total)
+	}))
+	t.Cleanup(srv.Close)
+
+	c, err := NewClient(srv.URL, "pul-token")
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	ctx := context.Background()
+
+	// Paging stops at the last page, before maxPages is reached.
+	resp, err := c.ListOrgDeployments(ctx, "org", 5)
+	if err != nil {
+		t.Fatalf("ListOrgDeployments() error: %v", err)
+	}
+	if len(resp.Deployments) != total || requests.Load() != 2 {
+		t.Errorf("expected %d deployments in 2 requests, got %d in %d", total, len(resp.Deployments), requests.Load())
+	}
+
+	requests.Store(0)
+	resp, err = c.ListOrgDeployments(ctx, "org", 1)
+	if err != nil {
+		t.Fatalf("ListOrgDeployments() error: %v", err)
+	}
+	if len(resp.Deployments) != 100 || requests.Load() != 1 || resp.Deployments[0].ID != "0" {
+		t.Errorf("expected the newest 100 deployments in 1 request, got %d in %d", len(resp.Deployments), requests.Load())
+	}
+}
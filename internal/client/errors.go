@@ -0,0 +1,74 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// maxErrorBodyLen bounds the response body snippet kept in an APIError.
+const maxErrorBodyLen = 256
+
+// APIError is returned when the Pulumi Cloud API responds with an unexpected
+// status or a body that cannot be decoded.
+type APIError struct {
+	// Endpoint is the Client method that made the request, e.g. "ListTeams".
+	Endpoint string
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// RequestID is the request ID reported by Pulumi Cloud, if any.
+	RequestID string
+	// Body is a truncated snippet of the response body.
+	Body string
+}
+
+// Error implements the error interface.
+func (e *APIError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "unexpected status %d from %s", e.StatusCode, e.Endpoint)
+	if e.RequestID != "" {
+		fmt.Fprintf(&b, " (request id %s)", e.RequestID)
+	}
+	if e.Body != "" {
+		fmt.Fprintf(&b, ": %s", e.Body)
+	}
+	return b.String()
+}
+
+// IsPermissionDenied reports whether err is an APIError for a 401 or 403 response,
+// meaning the access token is not allowed to use the endpoint.
+func IsPermissionDenied(err error) bool {
+	code := StatusCode(err)
+	return code == http.StatusUnauthorized || code == http.StatusForbidden
+}
+
+// StatusCode returns the HTTP status code of the APIError in err's chain, or 0
+// if err is not an APIError.
+func StatusCode(err error) int {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+func newAPIError(ctx context.Context, resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{Endpoint: endpointFromContext(ctx)}
+	if resp != nil {
+		apiErr.StatusCode = resp.StatusCode
+		apiErr.RequestID = resp.Header.Get("X-Pulumi-Request-Id")
+		if apiErr.RequestID == "" {
+			apiErr.RequestID = resp.Header.Get("X-Request-Id")
+		}
+	}
+
+	snippet := strings.TrimSpace(string(body))
+	if len(snippet) > maxErrorBodyLen {
+		snippet = snippet[:maxErrorBodyLen] + "..."
+	}
+	apiErr.Body = snippet
+
+	return apiErr
+}
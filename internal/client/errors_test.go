@@ -0,0 +1,48 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAPIErrorFromClient(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("X-Pulumi-Request-Id", "req-123")
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"code":403,"message":"` + strings.Repeat("x", 500) + `"}`))
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(srv.URL, "token")
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+
+	_, err = c.ListTeams(context.Background(), "my-org")
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *APIError, got %T: %v", err, err)
+	}
+	if apiErr.Endpoint != testEndpoint {
+		t.Errorf("expected endpoint %q, got %q", testEndpoint, apiErr.Endpoint)
+	}
+	if apiErr.StatusCode != http.StatusForbidden {
+		t.Errorf("expected status 403, got %d", apiErr.StatusCode)
+	}
+	if apiErr.RequestID != "req-123" {
+		t.Errorf("expected request id %q, got %q", "req-123", apiErr.RequestID)
+	}
+	if len(apiErr.Body) > maxErrorBodyLen+len("...") {
+		t.Errorf("expected body snippet to be truncated, got %d bytes", len(apiErr.Body))
+	}
+	if !IsPermissionDenied(err) {
+		t.Error("expected IsPermissionDenied to be true for a 403")
+	}
+}
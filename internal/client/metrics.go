@@ -0,0 +1,70 @@
+package client
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// instrumentedTransport records the exporter's own API request metrics for
+// every attempt, including retries, made through the next RoundTripper.
+type instrumentedTransport struct {
+	next     http.RoundTripper
+	requests metric.Int64Counter
+	errors   metric.Int64Counter
+	duration metric.Float64Histogram
+}
+
+func newInstrumentedTransport(next http.RoundTripper, meter metric.Meter) (*instrumentedTransport, error) {
+	t := &instrumentedTransport{next: next}
+	var err error
+
+	if t.requests, err = meter.Int64Counter("pulumi_exporter_api_requests_total",
+		metric.WithDescription("Number of Pulumi Cloud API requests made by the exporter"),
+	); err != nil {
+		return nil, err
+	}
+
+	if t.errors, err = meter.Int64Counter("pulumi_exporter_api_request_errors_total",
+		metric.WithDescription("Number of Pulumi Cloud API requests that failed or returned a non-2xx status"),
+	); err != nil {
+		return nil, err
+	}
+
+	if t.duration, err = meter.Float64Histogram("pulumi_exporter_api_request_duration_seconds",
+		metric.WithDescription("Latency of Pulumi Cloud API requests in seconds"),
+		metric.WithExplicitBucketBoundaries(0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30),
+	); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	elapsed := time.Since(start).Seconds()
+
+	code := "error"
+	if err == nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+
+	ctx := req.Context()
+	attrs := metric.WithAttributes(
+		attribute.String("endpoint", endpointFromContext(ctx)),
+		attribute.String("code", code),
+	)
+	t.requests.Add(ctx, 1, attrs)
+	t.duration.Record(ctx, elapsed, attrs)
+	if err != nil || resp.StatusCode < 200 || resp.StatusCode > 299 {
+		t.errors.Add(ctx, 1, attrs)
+	}
+
+	return resp, err
+}
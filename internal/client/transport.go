@@ -0,0 +1,174 @@
+package client
+
+import (
+	"context"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// responseHeaderTimeout bounds a single attempt. The overall request,
+	// including retries, is bounded by the caller's context.
+	responseHeaderTimeout = 30 * time.Second
+
+	retryBaseDelay = 500 * time.Millisecond
+	retryMaxDelay  = 30 * time.Second
+	// retryAfterMax caps how long a Retry-After header can make us wait.
+	retryAfterMax = 2 * time.Minute
+)
+
+type endpointKey struct{}
+
+// withEndpoint tags ctx with the logical API endpoint name, used for
+// per-endpoint retry budgets.
+func withEndpoint(ctx context.Context, endpoint string) context.Context {
+	return context.WithValue(ctx, endpointKey{}, endpoint)
+}
+
+func endpointFromContext(ctx context.Context) string {
+	if e, ok := ctx.Value(endpointKey{}).(string); ok {
+		return e
+	}
+	return "unknown"
+}
+
+// retryTransport is an http.RoundTripper that applies a global rate limit and
+// retries transient failures (network errors, 429 and 5xx responses) with
+// exponential backoff and jitter, honoring Retry-After. Retries are drawn
+// from a per-endpoint budget so a single failing endpoint cannot consume the
+// whole collection timeout.
+type retryTransport struct {
+	next        http.RoundTripper
+	limiter     *rate.Limiter
+	maxRetries  int
+	retryBudget int
+
+	mu      sync.Mutex
+	budgets map[string]*rate.Limiter
+}
+
+func newRetryTransport(next http.RoundTripper, o *options) *retryTransport {
+	limiter := rate.NewLimiter(rate.Inf, 0)
+	if o.rateLimit > 0 {
+		limiter = rate.NewLimiter(rate.Limit(o.rateLimit), max(o.rateBurst, 1))
+	}
+
+	return &retryTransport{
+		next:        next,
+		limiter:     limiter,
+		maxRetries:  o.maxRetries,
+		retryBudget: o.retryBudget,
+		budgets:     make(map[string]*rate.Limiter),
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	endpoint := endpointFromContext(ctx)
+
+	for attempt := 0; ; attempt++ {
+		if err := t.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		resp, err := t.next.RoundTrip(req)
+		if !t.shouldRetry(ctx, req, resp, err, attempt) || !t.budget(endpoint).Allow() {
+			return resp, err
+		}
+
+		wait := retryDelay(attempt, resp)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return resp, err
+		}
+
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (t *retryTransport) shouldRetry(ctx context.Context, req *http.Request, resp *http.Response, err error, attempt int) bool {
+	if attempt >= t.maxRetries || ctx.Err() != nil {
+		return false
+	}
+	// Only idempotent requests without a body can be replayed safely.
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// budget returns the retry budget for endpoint. The budget refills at
+// retryBudget retries per minute; a zero budget means unlimited retries.
+func (t *retryTransport) budget(endpoint string) *rate.Limiter {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b, ok := t.budgets[endpoint]
+	if !ok {
+		if t.retryBudget > 0 {
+			b = rate.NewLimiter(rate.Every(time.Minute/time.Duration(t.retryBudget)), t.retryBudget)
+		} else {
+			b = rate.NewLimiter(rate.Inf, 0)
+		}
+		t.budgets[endpoint] = b
+	}
+	return b
+}
+
+// retryDelay returns how long to wait before the next attempt: the server's
+// Retry-After when present, otherwise exponential backoff with jitter.
+func retryDelay(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if d, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			return min(d, retryAfterMax)
+		}
+	}
+
+	backoff := min(retryBaseDelay<<attempt, retryMaxDelay)
+	// Jitter in [backoff/2, backoff).
+	return backoff/2 + rand.N(backoff/2) //nolint:gosec // jitter does not need a secure source
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date.
+func parseRetryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
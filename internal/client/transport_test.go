@@ -0,0 +1,184 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+const testEndpoint = "ListTeams"
+
+func newTestTransport(o *options) *retryTransport {
+	return newRetryTransport(http.DefaultTransport, o)
+}
+
+func doGet(t *testing.T, rt http.RoundTripper, url string) *http.Response {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(withEndpoint(context.Background(), testEndpoint), 10*time.Second)
+	t.Cleanup(cancel)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		t.Fatalf("failed to create request: %v", err)
+	}
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip() error: %v", err)
+	}
+	t.Cleanup(func() { _ = resp.Body.Close() })
+	return resp
+}
+
+func TestRetryTransportRetriesWithRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	resp := doGet(t, newTestTransport(&options{maxRetries: 3}), srv.URL)
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("expected 3 attempts, got %d", got)
+	}
+}
+
+func TestRetryTransportStopsAtMaxRetries(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	resp := doGet(t, newTestTransport(&options{maxRetries: 2}), srv.URL)
+
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", resp.StatusCode)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("expected 3 attempts (1 + 2 retries), got %d", got)
+	}
+}
+
+func TestRetryTransportBudget(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	rt := newTestTransport(&options{maxRetries: 5, retryBudget: 1})
+
+	// The first request consumes the single retry in the endpoint's budget.
+	_ = doGet(t, rt, srv.URL)
+	_ = doGet(t, rt, srv.URL)
+
+	if got := calls.Load(); got != 3 {
+		t.Errorf("expected 3 attempts across both requests, got %d", got)
+	}
+}
+
+func TestRetryTransportNoRetryOnClientError(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	resp := doGet(t, newTestTransport(&options{maxRetries: 3}), srv.URL)
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected status 403, got %d", resp.StatusCode)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("expected a single attempt, got %d", got)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	if d, ok := parseRetryAfter("7"); !ok || d != 7*time.Second {
+		t.Errorf("parseRetryAfter(\"7\") = %v, %v; want 7s, true", d, ok)
+	}
+
+	future := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	if d, ok := parseRetryAfter(future); !ok || d <= 0 {
+		t.Errorf("parseRetryAfter(%q) = %v, %v; want positive duration", future, d, ok)
+	}
+
+	if _, ok := parseRetryAfter("soon"); ok {
+		t.Error("parseRetryAfter(\"soon\") should not parse")
+	}
+}
+
+func TestInstrumentedTransport(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	reader := sdkmetric.NewManualReader()
+	meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")
+
+	it, err := newInstrumentedTransport(http.DefaultTransport, meter)
+	if err != nil {
+		t.Fatalf("newInstrumentedTransport() error: %v", err)
+	}
+
+	// One initial attempt plus one retry, both recorded.
+	_ = doGet(t, newRetryTransport(it, &options{maxRetries: 1}), srv.URL)
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("failed to collect metrics: %v", err)
+	}
+
+	counts := make(map[string]int64)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if s, ok := m.Data.(metricdata.Sum[int64]); ok {
+				for _, dp := range s.DataPoints {
+					counts[m.Name] += dp.Value
+				}
+			}
+		}
+	}
+
+	if counts["pulumi_exporter_api_requests_total"] != 2 {
+		t.Errorf("pulumi_exporter_api_requests_total: got %d, want 2", counts["pulumi_exporter_api_requests_total"])
+	}
+	if counts["pulumi_exporter_api_request_errors_total"] != 2 {
+		t.Errorf("pulumi_exporter_api_request_errors_total: got %d, want 2", counts["pulumi_exporter_api_request_errors_total"])
+	}
+}
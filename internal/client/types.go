@@ -15,7 +15,7 @@ type StackSummary struct {
 	ProjectName   string `json:"projectName"`
 	StackName     string `json:"stackName"`
 	LastUpdate    int64  `json:"lastUpdate,omitempty"`
-	ResourceCount int    `json:"resourceCount,omitempty"`
+	ResourceCount *int   `json:"resourceCount,omitempty"`
 }
 
 // ListUpdatesResponse represents the response from GET /api/stacks/{org}/{project}/{stack}/updates.
@@ -25,12 +25,22 @@ type ListUpdatesResponse struct {
 
 // UpdateInfo represents a single stack update.
 type UpdateInfo struct {
-	Kind            string         `json:"kind"`
-	Result          string         `json:"result"`
-	StartTime       int64          `json:"startTime"`
-	EndTime         int64          `json:"endTime"`
-	ResourceChanges map[string]int `json:"resourceChanges,omitempty"`
-	Version         int            `json:"version"`
+	Kind            string            `json:"kind"`
+	Result          string            `json:"result"`
+	StartTime       int64             `json:"startTime"`
+	EndTime         int64             `json:"endTime"`
+	ResourceChanges map[string]int    `json:"resourceChanges,omitempty"`
+	Version         int               `json:"version"`
+	Environment     UpdateEnvironment `json:"environment"`
+}
+
+// UpdateEnvironment holds the keys of an update's environment map the
+// exporter uses. The other keys, such as git commits and CI build URLs, are
+// dropped.
+type UpdateEnvironment struct {
+	CLIVersion string `json:"pulumi.version,omitempty"`
+	ExecKind   string `json:"exec.kind,omitempty"`
+	CISystem   string `json:"ci.system,omitempty"`
 }
 
 // ResourceCountResponse represents the response from GET /api/stacks/{org}/{project}/{stack}/resources/count.
@@ -42,13 +52,40 @@ type ResourceCountResponse struct {
 // ListDeploymentsResponse represents the response from deployment list endpoints.
 type ListDeploymentsResponse struct {
 	Deployments []DeploymentInfo `json:"deployments"`
+	Total       int              `json:"total"`
 }
 
 // DeploymentInfo represents a single deployment.
 type DeploymentInfo struct {
-	ID      string `json:"id"`
-	Status  string `json:"status"`
-	Created string `json:"created"`
+	ID          string          `json:"id"`
+	Status      string          `json:"status"`
+	Created     string          `json:"created"`
+	Modified    string          `json:"modified"`
+	ProjectName string          `json:"projectName"`
+	StackName   string          `json:"stackName"`
+	Version     int             `json:"version"`
+	Operation   string          `json:"pulumiOperation"`
+	Initiator   string          `json:"initiator"`
+	AgentPool   string          `json:"agentPool"`
+	Paused      bool            `json:"paused"`
+	Jobs        []DeploymentJob `json:"jobs"`
+}
+
+// DeploymentJob represents one job of a deployment.
+type DeploymentJob struct {
+	Status      string           `json:"status"`
+	Started     time.Time        `json:"started"`
+	LastUpdated time.Time        `json:"lastUpdated"`
+	Steps       []DeploymentStep `json:"steps"`
+}
+
+// DeploymentStep represents one step of a deployment job, such as
+// "Install dependencies" or "Pulumi up".
+type DeploymentStep struct {
+	Name        string    `json:"name"`
+	Status      string    `json:"status"`
+	Started     time.Time `json:"started"`
+	LastUpdated time.Time `json:"lastUpdated"`
 }
 
 // ListMembersResponse represents the response from GET /api/orgs/{org}/members.
@@ -108,6 +145,28 @@ type PolicyGroupInfo struct {
 	IsOrgDefault          bool   `json:"isOrgDefault"`
 }
 
+// PolicyGroupResponse represents the response from GET /api/orgs/{org}/policygroups/{group}.
+type PolicyGroupResponse struct {
+	Name               string              `json:"name"`
+	IsOrgDefault       bool                `json:"isOrgDefault"`
+	Stacks             []PolicyGroupStack  `json:"stacks"`
+	AppliedPolicyPacks []AppliedPolicyPack `json:"appliedPolicyPacks"`
+}
+
+// PolicyGroupStack is a stack assigned to a policy group.
+type PolicyGroupStack struct {
+	ProjectName string `json:"routingProject"`
+	StackName   string `json:"name"`
+}
+
+// AppliedPolicyPack is a policy pack version enabled in a policy group.
+type AppliedPolicyPack struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+	Version     int64  `json:"version"`
+	VersionTag  string `json:"versionTag"`
+}
+
 // ListPolicyPacksResponse represents the response from GET /api/orgs/{org}/policypacks.
 type ListPolicyPacksResponse struct {
 	PolicyPacks []PolicyPackInfo `json:"policyPacks"`
@@ -115,8 +174,10 @@ type ListPolicyPacksResponse struct {
 
 // PolicyPackInfo represents a policy pack with versions.
 type PolicyPackInfo struct {
-	Name        string `json:"name"`
-	DisplayName string `json:"displayName"`
+	Name        string   `json:"name"`
+	DisplayName string   `json:"displayName"`
+	Versions    []int64  `json:"versions"`
+	VersionTags []string `json:"versionTags"`
 }
 
 // ListPolicyViolationsResponse represents the response from GET /api/orgs/{org}/policyresults/violationsv2.
@@ -127,13 +188,22 @@ type ListPolicyViolationsResponse struct {
 
 // PolicyViolation represents a policy violation.
 type PolicyViolation struct {
-	ID          string `json:"id"`
-	ProjectName string `json:"projectName"`
-	StackName   string `json:"stackName"`
-	PolicyPack  string `json:"policyPack"`
-	PolicyName  string `json:"policyName"`
-	Level       string `json:"level"`
-	Kind        string `json:"kind"`
+	ID              string    `json:"id"`
+	ProjectName     string    `json:"projectName"`
+	StackName       string    `json:"stackName"`
+	StackVersion    int64     `json:"stackVersion,omitempty"`
+	AccountName     string    `json:"accountName,omitempty"`
+	PolicyPack      string    `json:"policyPack"`
+	PolicyPackTag   string    `json:"policyPackTag"`
+	PolicyName      string    `json:"policyName"`
+	ResourceURN     string    `json:"resourceURN"`
+	ResourceType    string    `json:"resourceType"`
+	ResourceName    string    `json:"resourceName"`
+	ResourceVersion int64     `json:"resourceVersion,omitempty"`
+	Message         string    `json:"message"`
+	ObservedAt      time.Time `json:"observedAt"`
+	Level           string    `json:"level"`
+	Kind            string    `json:"kind"`
 }
 
 // PolicyResultsMetadataResponse represents the response from GET /api/orgs/{org}/policyresults/metadata.
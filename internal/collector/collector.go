@@ -3,13 +3,20 @@ package collector
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
 	"golang.org/x/sync/errgroup"
 
+	"github.com/pulumi-labs/pulumi-exporter/internal/checkpoint"
 	"github.com/pulumi-labs/pulumi-exporter/internal/client"
 	"github.com/pulumi-labs/pulumi-exporter/internal/config"
 )
@@ -19,13 +26,15 @@ type PulumiAPI interface {
 	ListStacks(ctx context.Context) (*client.ListStacksResponse, error)
 	GetResourceCount(ctx context.Context, org, project, stack string) (*client.ResourceCountResponse, error)
 	ListUpdates(ctx context.Context, org, project, stack string, page, pageSize int) (*client.ListUpdatesResponse, error)
-	ListOrgDeployments(ctx context.Context, org string) (*client.ListDeploymentsResponse, error)
+	ListOrgDeployments(ctx context.Context, org string, maxPages int) (*client.ListDeploymentsResponse, error)
+	ListStackDeployments(ctx context.Context, org, project, stack string, page, pageSize int) (*client.ListDeploymentsResponse, error)
 	ListMembers(ctx context.Context, org string) (*client.ListMembersResponse, error)
 	ListTeams(ctx context.Context, org string) (*client.ListTeamsResponse, error)
 	ListEnvironments(ctx context.Context, org string) (*client.ListEnvironmentsResponse, error)
 	ListPolicyGroups(ctx context.Context, org string) (*client.ListPolicyGroupsResponse, error)
+	GetPolicyGroup(ctx context.Context, org, group string) (*client.PolicyGroupResponse, error)
 	ListPolicyPacks(ctx context.Context, org string) (*client.ListPolicyPacksResponse, error)
-	ListPolicyViolations(ctx context.Context, org string) (*client.ListPolicyViolationsResponse, error)
+	ListPolicyViolations(ctx context.Context, org string, maxPages int) (*client.ListPolicyViolationsResponse, bool, error)
 	ListNeoTasks(ctx context.Context, org string) (*client.ListNeoTasksResponse, error)
 	GetOrgNeoTokenBudget(ctx context.Context, org string) (*client.NeoTokenBudgetResponse, error)
 	GetPolicyResultsMetadata(ctx context.Context, org string) (*client.PolicyResultsMetadataResponse, error)
@@ -34,79 +43,358 @@ type PulumiAPI interface {
 // Collector periodically collects metrics from the Pulumi Cloud API.
 type Collector struct {
 	client          PulumiAPI
+	checkpoints     checkpoint.Store
+	saveMu          sync.Mutex
 	cfg             *config.Config
 	logger          *slog.Logger
 	mu              sync.Mutex
 	lastSeenVersion map[string]int
+	stackStates     map[string]stackState
+	pendingUpdates  map[string]map[int]struct{}
+	history         *updateHistory
+	deniedFamilies  map[string]struct{}
 	instruments     *Instruments
+	status          *statusTracker
+	filter          *stackFilter
+	poller          *pollScheduler
+
+	// deploymentStacks selects the stacks whose latest deployment is collected.
+	deploymentStacks []stackPattern
+	// deniedStackDeployments holds the orgs whose per-stack deployment
+	// lookups were refused with a 401/403.
+	deniedStackDeployments map[string]struct{}
+	// recordedDeployments holds, per org, the IDs of finished deployments
+	// already added to the deployment counters and histograms.
+	recordedDeployments map[string]map[string]struct{}
+	// openViolations holds, per org, the policy violations open at the
+	// last successful collection by ID.
+	openViolations map[string]map[string]openViolation
+	// lastStacks holds the stacks returned by the latest ListStacks call of
+	// the stacks family, for the policy coverage of the policies family.
+	lastStacks []client.StackSummary
+	// requiredPacks are the policy packs stacks must have enabled.
+	requiredPacks []requiredPolicyPack
 }
 
-// NewCollector creates a new Collector.
-func NewCollector(apiClient PulumiAPI, cfg *config.Config, meter metric.Meter, logger *slog.Logger) (*Collector, error) {
+// NewCollector creates a new Collector. The checkpoint store is loaded when
+// Run starts and written after every collection cycle.
+func NewCollector(apiClient PulumiAPI, checkpoints checkpoint.Store, cfg *config.Config, meter metric.Meter, logger *slog.Logger) (*Collector, error) {
 	instruments, err := NewInstruments(meter)
 	if err != nil {
 		return nil, err
 	}
 
+	filter, err := newStackFilter(cfg.Pulumi.StackFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	deploymentStacks, err := compileStackPatterns(cfg.Pulumi.DeploymentStacks)
+	if err != nil {
+		return nil, err
+	}
+
+	requiredPacks, err := compileRequiredPolicyPacks(cfg.Pulumi.RequiredPolicyPacks)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Collector{
 		client:          apiClient,
+		checkpoints:     checkpoints,
 		cfg:             cfg,
 		logger:          logger,
 		lastSeenVersion: make(map[string]int),
+		stackStates:     make(map[string]stackState),
+		pendingUpdates:  make(map[string]map[int]struct{}),
+		history:         newUpdateHistory(),
+		deniedFamilies:  make(map[string]struct{}),
 		instruments:     instruments,
+		status:          newStatusTracker(),
+		filter:          filter,
+		poller:          newPollScheduler(cfg.Pulumi.Polling),
+
+		deploymentStacks:       deploymentStacks,
+		deniedStackDeployments: make(map[string]struct{}),
+		recordedDeployments:    make(map[string]map[string]struct{}),
+		openViolations:         make(map[string]map[string]openViolation),
+		requiredPacks:          requiredPacks,
 	}, nil
 }
 
-// Run starts the collection loop, polling at the configured interval.
+// Run collects every enabled metric family immediately, then each family on
+// its own interval until ctx is cancelled.
 func (c *Collector) Run(ctx context.Context) error {
-	c.logger.Info("starting collector", "interval", c.cfg.Pulumi.CollectInterval)
+	c.logger.Info("starting collector", "interval", c.cfg.Pulumi.CollectInterval, "families", c.enabledFamilies())
+
+	c.loadCheckpoints(ctx)
 
 	// Collect immediately on start.
 	c.collect(ctx)
 
-	ticker := time.NewTicker(c.cfg.Pulumi.CollectInterval)
+	var wg sync.WaitGroup
+	for _, family := range c.enabledFamilies() {
+		wg.Go(func() { c.runFamily(ctx, family) })
+	}
+	wg.Wait()
+
+	c.logger.Info("collector stopped")
+	return ctx.Err()
+}
+
+// runFamily collects family every interval until ctx is cancelled.
+func (c *Collector) runFamily(ctx context.Context, family string) {
+	ticker := time.NewTicker(c.familyInterval(family))
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
-			c.logger.Info("collector stopped")
-			return ctx.Err()
+			return
 		case <-ticker.C:
-			c.collect(ctx)
+			c.collectFamily(ctx, family)
 		}
 	}
 }
 
-func (c *Collector) collect(ctx context.Context) {
-	c.logger.Info("collecting metrics")
+// Ready reports whether the exporter is ready to serve metrics: the first
+// collection has completed and ListStacks succeeded in the last
+// ReadyCycles cycles. With the stacks family disabled, the exporter is ready
+// once any family completed a cycle.
+func (c *Collector) Ready() bool {
+	if c.familyDisabled(familyStacks) {
+		return c.status.anyCycleCompleted()
+	}
+	return c.status.ready(c.cfg.Web.ReadyCycles)
+}
 
-	// Apply a collection timeout: 90% of the collect interval, clamped to a 10s minimum.
-	timeout := c.cfg.Pulumi.CollectInterval * 9 / 10
-	if timeout < 10*time.Second {
-		timeout = 10 * time.Second
+// Healthy reports whether the collection loops are live. It turns false when
+// a family's cycle runs longer than its timeout plus one interval, or when no
+// new cycle has started within two intervals of the previous one ending.
+func (c *Collector) Healthy() bool {
+	now := time.Now()
+	for _, family := range c.enabledFamilies() {
+		interval := c.familyInterval(family)
+		if !c.status.healthy(family, now, c.familyTimeout(family)+interval, 2*interval) {
+			return false
+		}
+	}
+	return true
+}
+
+// Status returns a snapshot of the collector's readiness, liveness and
+// per-org, per-family collection results.
+func (c *Collector) Status() Status {
+	st := c.status.snapshot()
+	st.Ready = c.Ready()
+	st.Healthy = c.Healthy()
+	return st
+}
+
+// enabledFamilies returns the metric families that are not disabled.
+func (c *Collector) enabledFamilies() []string {
+	var families []string
+	for _, family := range config.Families {
+		if !c.familyDisabled(family) {
+			families = append(families, family)
+		}
 	}
+	return families
+}
+
+func (c *Collector) familyDisabled(family string) bool {
+	return c.cfg.Collectors.Family(family).Disabled
+}
+
+// familyInterval returns the collection interval of family, defaulting to
+// the global collect interval.
+func (c *Collector) familyInterval(family string) time.Duration {
+	if interval := c.cfg.Collectors.Family(family).Interval; interval > 0 {
+		return interval
+	}
+	return c.cfg.Pulumi.CollectInterval
+}
+
+// familyTimeout returns the timeout of one collection cycle of family:
+// the configured timeout, or 90% of its interval clamped to a 10s minimum.
+func (c *Collector) familyTimeout(family string) time.Duration {
+	if timeout := c.cfg.Collectors.Family(family).Timeout; timeout > 0 {
+		return timeout
+	}
+	return max(c.familyInterval(family)*9/10, 10*time.Second)
+}
+
+// collect runs one cycle of every enabled family concurrently.
+func (c *Collector) collect(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, family := range c.enabledFamilies() {
+		wg.Go(func() { c.collectFamily(ctx, family) })
+	}
+	wg.Wait()
+}
+
+// collectFamily runs one collection cycle of family under its timeout.
+func (c *Collector) collectFamily(ctx context.Context, family string) {
+	c.logger.Info("collecting metrics", "family", family)
+	start := time.Now()
+	c.status.startCycle(family, start)
+
+	timeout := c.familyTimeout(family)
 	collectCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	stacks, err := c.client.ListStacks(collectCtx)
+	defer func() {
+		c.status.endCycle(family, time.Now())
+		attrs := metric.WithAttributes(attribute.String("family", family))
+		c.instruments.collectDuration.Record(ctx, time.Since(start).Seconds(), attrs)
+		if errors.Is(collectCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+			c.logger.Warn("collection cycle hit the collection timeout", "family", family, "timeout", timeout)
+			c.instruments.collectTimeouts.Add(ctx, 1, attrs)
+		}
+	}()
+
+	if family == familyStacks {
+		c.collectStackFamily(collectCtx)
+		c.saveCheckpoints(ctx)
+	} else {
+		// Collect org-level families with bounded parallelism.
+		fn := c.orgFamilyCollector(family)
+		g, gCtx := errgroup.WithContext(collectCtx)
+		g.SetLimit(3)
+		for _, org := range c.cfg.Pulumi.Organizations {
+			g.Go(func() error {
+				c.collectOrgFamily(gCtx, org, family, fn)
+				return nil
+			})
+		}
+		_ = g.Wait()
+		if family == familyDeployments {
+			c.saveCheckpoints(ctx)
+		}
+	}
+
+	c.logger.Info("collection complete", "family", family)
+}
+
+// collectStackFamily lists the stacks of all organizations and collects the
+// ones of the configured organizations.
+func (c *Collector) collectStackFamily(ctx context.Context) {
+	start := time.Now()
+
+	stacks, err := c.client.ListStacks(ctx)
+	c.status.recordListStacks(err)
 	if err != nil {
 		c.logger.Error("failed to list stacks", "error", err)
+		for _, org := range c.cfg.Pulumi.Organizations {
+			c.status.recordFamily(org, familyStacks, start, time.Now(), err, false)
+		}
 		return
 	}
 
-	// Build a set of configured organizations for filtering.
-	orgSet := make(map[string]struct{}, len(c.cfg.Pulumi.Organizations))
+	c.mu.Lock()
+	c.lastStacks = stacks.Stacks
+	c.mu.Unlock()
+
+	c.collectStacks(ctx, stacks.Stacks)
+}
+
+// stackOutcomes counts how the stacks of one organization fared in a cycle.
+type stackOutcomes struct {
+	processed atomic.Int64
+	skipped   atomic.Int64
+	failed    atomic.Int64
+	filtered  atomic.Int64
+	unchanged atomic.Int64
+	deferred  atomic.Int64
+}
+
+// collectStacks fans out per-stack collection for the stacks of the configured
+// organizations that pass the stack filter and reports how many were
+// processed, skipped, failed, filtered out, unchanged or deferred. With
+// adaptive polling, only stacks due in their polling tier are collected, most
+// active tier first.
+func (c *Collector) collectStacks(ctx context.Context, stacks []client.StackSummary) {
+	start := time.Now()
+
+	// Track outcomes per configured organization; this doubles as the org filter.
+	outcomes := make(map[string]*stackOutcomes, len(c.cfg.Pulumi.Organizations))
 	for _, org := range c.cfg.Pulumi.Organizations {
-		orgSet[org] = struct{}{}
+		outcomes[org] = &stackOutcomes{}
+	}
+
+	// Stacks no longer returned by ListStacks, or filtered out, stop being reported.
+	listed := make(map[string]struct{}, len(stacks))
+	var listedStacks []client.StackSummary
+	now := time.Now()
+
+	adaptive := c.cfg.Pulumi.Polling.Adaptive
+	tierCounts := make(map[string]map[string]int64, len(outcomes))
+	for org := range outcomes {
+		tierCounts[org] = make(map[string]int64)
+	}
+
+	type dueStack struct {
+		stack client.StackSummary
+		tier  int
+	}
+	var due []dueStack
+
+	for _, stack := range stacks {
+		outcome, ok := outcomes[stack.OrgName]
+		if !ok {
+			continue
+		}
+		if !c.filter.match(stack, now) {
+			outcome.filtered.Add(1)
+			continue
+		}
+		stackKey := stack.OrgName + "/" + stack.ProjectName + "/" + stack.StackName
+		listed[stackScope(stackKey)] = struct{}{}
+		listedStacks = append(listedStacks, stack)
+
+		c.mu.Lock()
+		state, known := c.stackStates[stackKey]
+		c.mu.Unlock()
+
+		tier := c.poller.tier(stack, state, now)
+		if adaptive {
+			tierCounts[stack.OrgName][c.poller.tiers[tier].Name]++
+		}
+
+		// In incremental mode, stacks that were not updated keep their gauges.
+		// A stack whose last update was still running is collected until it
+		// ends, and a stack whose latest deployment is collected every cycle,
+		// since deployments change without updating the stack.
+		if c.cfg.Pulumi.Incremental && known && !state.inProgress && state.lastUpdate == stack.LastUpdate &&
+			!c.collectsStackDeployments(stack) {
+			outcome.unchanged.Add(1)
+			continue
+		}
+
+		if adaptive && !c.poller.due(tier, stack, state, known, now) {
+			outcome.deferred.Add(1)
+			continue
+		}
+		due = append(due, dueStack{stack: stack, tier: tier})
+	}
+
+	// Collect the most active tiers first, so dormant stacks are the ones
+	// skipped when a cycle runs out of time.
+	if adaptive {
+		slices.SortStableFunc(due, func(a, b dueStack) int { return a.tier - b.tier })
 	}
 
 	// Fan out stack collection with a semaphore.
 	sem := make(chan struct{}, c.cfg.Pulumi.MaxConcurrency)
 	var wg sync.WaitGroup
 
-	for _, stack := range stacks.Stacks {
-		if _, ok := orgSet[stack.OrgName]; !ok {
+	for _, d := range due {
+		stack := d.stack
+		outcome := outcomes[stack.OrgName]
+
+		// Stacks left over once the collection timeout has passed are skipped.
+		if ctx.Err() != nil {
+			outcome.skipped.Add(1)
 			continue
 		}
 
@@ -117,23 +405,138 @@ func (c *Collector) collect(ctx context.Context) {
 			defer wg.Done()
 			defer func() { <-sem }()
 
-			c.collectStack(collectCtx, s)
+			if err := c.collectStack(ctx, s); err != nil {
+				outcome.failed.Add(1)
+				return
+			}
+			outcome.processed.Add(1)
 		}(stack)
 	}
 
 	wg.Wait()
 
-	// Collect org-level metrics with bounded parallelism.
-	g, gCtx := errgroup.WithContext(collectCtx)
-	g.SetLimit(3)
-	for _, org := range c.cfg.Pulumi.Organizations {
-		g.Go(func() error {
-			c.collectOrgDeployments(gCtx, org)
-			c.collectOrgMetrics(gCtx, org)
-			return nil
-		})
+	c.instruments.gauges.prune(stackScope(""), listed)
+	c.pruneStackStates(listed)
+	c.recordStackHistories(listedStacks, time.Now())
+
+	for org, outcome := range outcomes {
+		c.recordStackOutcomes(ctx, org, start, outcome)
+		if adaptive {
+			c.recordPollTiers(org, tierCounts[org])
+		}
+	}
+}
+
+// pruneStackStates forgets the state of every stack whose gauge scope is not
+// in listed, so a stack that reappears is collected in full. Pending updates
+// are kept like the last seen versions, so updates that were in progress are
+// still counted when the stack reappears.
+func (c *Collector) pruneStackStates(listed map[string]struct{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for stackKey := range c.stackStates {
+		if _, ok := listed[stackScope(stackKey)]; !ok {
+			delete(c.stackStates, stackKey)
+		}
 	}
-	_ = g.Wait()
+}
 
-	c.logger.Info("collection complete")
+// recordPollTiers reports the number of stacks of org in each polling tier.
+func (c *Collector) recordPollTiers(org string, counts map[string]int64) {
+	var gauges gaugeBatch
+	for tier, count := range countBuckets(counts, c.poller.tierNames()...) {
+		gauges.addInt64(c.instruments.pollTierStacks, count,
+			attribute.String("org", org),
+			attribute.String("tier", tier),
+		)
+	}
+	c.instruments.gauges.replace(orgScope(org, "poll_tiers"), &gauges)
+}
+
+func (c *Collector) recordStackOutcomes(ctx context.Context, org string, start time.Time, outcome *stackOutcomes) {
+	counts := map[string]int64{
+		"processed": outcome.processed.Load(),
+		"skipped":   outcome.skipped.Load(),
+		"failed":    outcome.failed.Load(),
+		"filtered":  outcome.filtered.Load(),
+		"unchanged": outcome.unchanged.Load(),
+		"deferred":  outcome.deferred.Load(),
+	}
+	for name, count := range counts {
+		c.instruments.collectStacks.Add(ctx, count, metric.WithAttributes(
+			attribute.String("org", org),
+			attribute.String("outcome", name),
+		))
+	}
+
+	var err error
+	if counts["skipped"] > 0 || counts["failed"] > 0 {
+		err = fmt.Errorf("%d stacks failed, %d skipped", counts["failed"], counts["skipped"])
+	}
+	c.recordFamilyResult(ctx, org, familyStacks, start, err)
+}
+
+// loadCheckpoints restores the last seen update versions, the updates still
+// in progress and the recorded deployments from the checkpoint store. A
+// failed load is logged and collection continues from scratch, which follows
+// the configured seed policy for every stack and org.
+func (c *Collector) loadCheckpoints(ctx context.Context) {
+	state, err := c.checkpoints.Load(ctx)
+	if err != nil {
+		c.logger.Error("failed to load checkpoints", "error", err)
+		return
+	}
+
+	c.mu.Lock()
+	for key, version := range state.Stacks {
+		if version > c.lastSeenVersion[key] {
+			c.lastSeenVersion[key] = version
+		}
+	}
+	for key, versions := range state.Pending {
+		if _, ok := c.pendingUpdates[key]; ok {
+			continue
+		}
+		pending := make(map[int]struct{}, len(versions))
+		for _, v := range versions {
+			pending[v] = struct{}{}
+		}
+		c.pendingUpdates[key] = pending
+	}
+	for org, ids := range state.Deployments {
+		if _, ok := c.recordedDeployments[org]; ok {
+			continue
+		}
+		recorded := make(map[string]struct{}, len(ids))
+		for _, id := range ids {
+			recorded[id] = struct{}{}
+		}
+		c.recordedDeployments[org] = recorded
+	}
+	c.mu.Unlock()
+
+	c.logger.Info("loaded checkpoints", "stacks", len(state.Stacks), "orgs", len(state.Deployments))
+}
+
+// saveCheckpoints writes the current last seen update versions, updates still
+// in progress and recorded deployments to the checkpoint store. Saves are
+// serialized so that a stale snapshot never overwrites a newer one.
+func (c *Collector) saveCheckpoints(ctx context.Context) {
+	c.saveMu.Lock()
+	defer c.saveMu.Unlock()
+
+	state := checkpoint.NewState()
+	c.mu.Lock()
+	maps.Copy(state.Stacks, c.lastSeenVersion)
+	for key, pending := range c.pendingUpdates {
+		state.Pending[key] = slices.Sorted(maps.Keys(pending))
+	}
+	for org, recorded := range c.recordedDeployments {
+		state.Deployments[org] = slices.Sorted(maps.Keys(recorded))
+	}
+	c.mu.Unlock()
+
+	if err := c.checkpoints.Save(ctx, state); err != nil {
+		c.logger.Error("failed to save checkpoints", "error", err)
+	}
 }
@@ -2,15 +2,21 @@ package collector
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/metric"
 	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 
+	"github.com/pulumi-labs/pulumi-exporter/internal/checkpoint"
 	"github.com/pulumi-labs/pulumi-exporter/internal/client"
 	"github.com/pulumi-labs/pulumi-exporter/internal/config"
 )
@@ -25,30 +31,78 @@ const (
 )
 
 type mockAPI struct {
-	stacks      *client.ListStacksResponse
-	resources   map[string]*client.ResourceCountResponse
-	updates     map[string]*client.ListUpdatesResponse
-	deployments map[string]*client.ListDeploymentsResponse
-	neoTasks    map[string]*client.ListNeoTasksResponse
-	neoBudget   map[string]*client.NeoTokenBudgetResponse
+	stacks           *client.ListStacksResponse
+	stacksErr        error
+	resources        map[string]*client.ResourceCountResponse
+	updates          map[string]*client.ListUpdatesResponse
+	history          map[string][]client.UpdateInfo
+	deployments      map[string]*client.ListDeploymentsResponse
+	stackDeployments map[string]*client.ListDeploymentsResponse
+	stackDeployErr   error
+	violations       map[string]*client.ListPolicyViolationsResponse
+	policyGroups     map[string]*client.ListPolicyGroupsResponse
+	policyGroup      map[string]*client.PolicyGroupResponse
+	policyPacks      map[string]*client.ListPolicyPacksResponse
+	policyPacksErr   error
+	neoTasks         map[string]*client.ListNeoTasksResponse
+	neoBudget        map[string]*client.NeoTokenBudgetResponse
+	teamsErr         error
+	teamsCalls       int
+
+	resourceCalls   atomic.Int64
+	updatesCalls    atomic.Int64
+	deploymentCalls atomic.Int64
 }
 
 func (m *mockAPI) ListStacks(_ context.Context) (*client.ListStacksResponse, error) {
+	if m.stacksErr != nil {
+		return nil, m.stacksErr
+	}
+	if m.stacks == nil {
+		return &client.ListStacksResponse{}, nil
+	}
 	return m.stacks, nil
 }
 
 func (m *mockAPI) GetResourceCount(_ context.Context, org, project, stack string) (*client.ResourceCountResponse, error) {
+	m.resourceCalls.Add(1)
 	key := org + "/" + project + "/" + stack
 	return m.resources[key], nil
 }
 
-func (m *mockAPI) ListUpdates(_ context.Context, org, project, stack string, _, _ int) (*client.ListUpdatesResponse, error) {
+func (m *mockAPI) ListUpdates(_ context.Context, org, project, stack string, page, pageSize int) (*client.ListUpdatesResponse, error) {
+	m.updatesCalls.Add(1)
 	key := org + "/" + project + "/" + stack
-	return m.updates[key], nil
+	if h, ok := m.history[key]; ok {
+		start := min((page-1)*pageSize, len(h))
+		end := min(start+pageSize, len(h))
+		return &client.ListUpdatesResponse{Updates: h[start:end]}, nil
+	}
+	if r := m.updates[key]; r != nil {
+		return r, nil
+	}
+	return &client.ListUpdatesResponse{}, nil
+}
+
+// ListOrgDeployments returns the first maxPages pages of 100 deployments of org.
+func (m *mockAPI) ListOrgDeployments(_ context.Context, org string, maxPages int) (*client.ListDeploymentsResponse, error) {
+	m.deploymentCalls.Add(1)
+	resp := m.deployments[org]
+	if resp == nil {
+		return &client.ListDeploymentsResponse{}, nil
+	}
+	end := min(maxPages*100, len(resp.Deployments))
+	return &client.ListDeploymentsResponse{Deployments: resp.Deployments[:end], Total: len(resp.Deployments)}, nil
 }
 
-func (m *mockAPI) ListOrgDeployments(_ context.Context, org string) (*client.ListDeploymentsResponse, error) {
-	return m.deployments[org], nil
+func (m *mockAPI) ListStackDeployments(_ context.Context, org, project, stack string, _, _ int) (*client.ListDeploymentsResponse, error) {
+	if m.stackDeployErr != nil {
+		return nil, m.stackDeployErr
+	}
+	if r := m.stackDeployments[org+"/"+project+"/"+stack]; r != nil {
+		return r, nil
+	}
+	return &client.ListDeploymentsResponse{}, nil
 }
 
 func (m *mockAPI) ListMembers(_ context.Context, _ string) (*client.ListMembersResponse, error) {
@@ -56,6 +110,10 @@ func (m *mockAPI) ListMembers(_ context.Context, _ string) (*client.ListMembersR
 }
 
 func (m *mockAPI) ListTeams(_ context.Context, _ string) (*client.ListTeamsResponse, error) {
+	m.teamsCalls++
+	if m.teamsErr != nil {
+		return nil, m.teamsErr
+	}
 	return &client.ListTeamsResponse{}, nil
 }
 
@@ -63,16 +121,39 @@ func (m *mockAPI) ListEnvironments(_ context.Context, _ string) (*client.ListEnv
 	return &client.ListEnvironmentsResponse{}, nil
 }
 
-func (m *mockAPI) ListPolicyGroups(_ context.Context, _ string) (*client.ListPolicyGroupsResponse, error) {
+func (m *mockAPI) ListPolicyGroups(_ context.Context, org string) (*client.ListPolicyGroupsResponse, error) {
+	if resp, ok := m.policyGroups[org]; ok {
+		return resp, nil
+	}
 	return &client.ListPolicyGroupsResponse{}, nil
 }
 
-func (m *mockAPI) ListPolicyPacks(_ context.Context, _ string) (*client.ListPolicyPacksResponse, error) {
+func (m *mockAPI) GetPolicyGroup(_ context.Context, org, group string) (*client.PolicyGroupResponse, error) {
+	if resp, ok := m.policyGroup[org+"/"+group]; ok {
+		return resp, nil
+	}
+	return &client.PolicyGroupResponse{Name: group}, nil
+}
+
+func (m *mockAPI) ListPolicyPacks(_ context.Context, org string) (*client.ListPolicyPacksResponse, error) {
+	if m.policyPacksErr != nil {
+		return nil, m.policyPacksErr
+	}
+	if resp, ok := m.policyPacks[org]; ok {
+		return resp, nil
+	}
 	return &client.ListPolicyPacksResponse{}, nil
 }
 
-func (m *mockAPI) ListPolicyViolations(_ context.Context, _ string) (*client.ListPolicyViolationsResponse, error) {
-	return &client.ListPolicyViolationsResponse{}, nil
+// ListPolicyViolations returns the violations of org in pages of two, up to
+// maxPages pages.
+func (m *mockAPI) ListPolicyViolations(_ context.Context, org string, maxPages int) (*client.ListPolicyViolationsResponse, bool, error) {
+	r := m.violations[org]
+	if r == nil {
+		return &client.ListPolicyViolationsResponse{}, false, nil
+	}
+	end := min(maxPages*2, len(r.PolicyViolations))
+	return &client.ListPolicyViolationsResponse{PolicyViolations: r.PolicyViolations[:end]}, end < len(r.PolicyViolations), nil
 }
 
 func (m *mockAPI) ListNeoTasks(_ context.Context, org string) (*client.ListNeoTasksResponse, error) {
@@ -106,7 +187,7 @@ func newTestCollector(t *testing.T, api PulumiAPI) (*Collector, *sdkmetric.Manua
 		},
 	}
 
-	c, err := NewCollector(api, cfg, meter, slog.Default())
+	c, err := NewCollector(api, checkpoint.NewMemoryStore(), cfg, meter, slog.Default())
 	if err != nil {
 		t.Fatalf("failed to create collector: %v", err)
 	}
@@ -149,7 +230,9 @@ func TestCollectStack(t *testing.T) {
 		StackName:   "dev",
 	}
 
-	c.collectStack(ctx, stack)
+	if err := c.collectStack(ctx, stack); err != nil {
+		t.Fatalf("collectStack() error: %v", err)
+	}
 
 	var rm metricdata.ResourceMetrics
 	if err := reader.Collect(ctx, &rm); err != nil {
@@ -204,7 +287,9 @@ func TestCollectOrgDeployments(t *testing.T) {
 	c, reader := newTestCollector(t, api)
 	ctx := context.Background()
 
-	c.collectOrgDeployments(ctx, testOrg)
+	if err := c.collectOrgDeployments(ctx, testOrg); err != nil {
+		t.Fatalf("collectOrgDeployments() error: %v", err)
+	}
 
 	var rm metricdata.ResourceMetrics
 	if err := reader.Collect(ctx, &rm); err != nil {
@@ -257,8 +342,9 @@ func TestCollectNeoTokens(t *testing.T) {
 	c, reader := newTestCollector(t, api)
 	ctx := context.Background()
 
-	c.collectNeoTasks(ctx, testOrg)
-	c.collectNeoTokenBudget(ctx, testOrg, metric.WithAttributes(attribute.String("org", testOrg)))
+	if err := c.collectNeo(ctx, testOrg); err != nil {
+		t.Fatalf("collectNeo() error: %v", err)
+	}
 
 	var rm metricdata.ResourceMetrics
 	if err := reader.Collect(ctx, &rm); err != nil {
@@ -284,6 +370,25 @@ func TestCollectNeoTokens(t *testing.T) {
 	}
 }
 
+func TestPermissionDeniedDisablesFamily(t *testing.T) {
+	t.Parallel()
+
+	api := &mockAPI{
+		teamsErr: fmt.Errorf("listing teams: %w", &client.APIError{Endpoint: "ListTeams", StatusCode: http.StatusForbidden}),
+	}
+
+	c, _ := newTestCollector(t, api)
+	ctx := context.Background()
+
+	c.collectOrgFamily(ctx, testOrg, familyTeams, c.collectTeams)
+	c.collectOrgFamily(ctx, testOrg, familyTeams, c.collectTeams)
+
+	// After the 403 the family is disabled, so the second cycle makes no API call.
+	if api.teamsCalls != 1 {
+		t.Errorf("expected 1 ListTeams call, got %d", api.teamsCalls)
+	}
+}
+
 // sumInt64Gauge returns the sum of all data point values for the named int64 gauge.
 func sumInt64Gauge(t *testing.T, rm metricdata.ResourceMetrics, name string) int64 {
 	t.Helper()
@@ -334,7 +439,9 @@ func TestLastSeenVersionTracking(t *testing.T) {
 	}
 
 	// First collection should process both updates.
-	c.collectStack(ctx, stack)
+	if err := c.collectStack(ctx, stack); err != nil {
+		t.Fatalf("collectStack() error: %v", err)
+	}
 
 	var rm metricdata.ResourceMetrics
 	if err := reader.Collect(ctx, &rm); err != nil {
@@ -348,7 +455,9 @@ func TestLastSeenVersionTracking(t *testing.T) {
 	}
 
 	// Second collection with same updates should not increment counters.
-	c.collectStack(ctx, stack)
+	if err := c.collectStack(ctx, stack); err != nil {
+		t.Fatalf("collectStack() error: %v", err)
+	}
 
 	if err := reader.Collect(ctx, &rm); err != nil {
 		t.Fatalf("failed to collect: %v", err)
@@ -360,6 +469,183 @@ func TestLastSeenVersionTracking(t *testing.T) {
 	}
 }
 
+func TestSeedPolicyLatest(t *testing.T) {
+	t.Parallel()
+
+	api := &mockAPI{
+		resources: map[string]*client.ResourceCountResponse{
+			testStackKey: {Count: 10, Version: 2},
+		},
+		updates: map[string]*client.ListUpdatesResponse{
+			testStackKey: {
+				Updates: []client.UpdateInfo{
+					{Kind: testUpdateKind, Result: testResultOK, StartTime: 1000, EndTime: 1060, Version: 1},
+					{Kind: testUpdateKind, Result: testResultOK, StartTime: 2000, EndTime: 2120, Version: 2},
+				},
+			},
+		},
+	}
+
+	c, reader := newTestCollector(t, api)
+	c.cfg.Checkpoint.SeedPolicy = config.SeedPolicyLatest
+	ctx := context.Background()
+
+	if err := c.collectStack(ctx, client.StackSummary{OrgName: testOrg, ProjectName: "my-project", StackName: "dev"}); err != nil {
+		t.Fatalf("collectStack() error: %v", err)
+	}
+
+	// The stack was seen for the first time, so its history is not counted.
+	if c.lastSeenVersion[testStackKey] != 2 {
+		t.Errorf("expected lastSeenVersion=2, got %d", c.lastSeenVersion[testStackKey])
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatalf("failed to collect: %v", err)
+	}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == "pulumi_update_total" {
+				t.Errorf("expected no pulumi_update_total data for seeded stack, got %+v", m.Data)
+			}
+		}
+	}
+}
+
+func TestCheckpointsRestoredOnRun(t *testing.T) {
+	t.Parallel()
+
+	store := checkpoint.NewMemoryStore()
+	state := checkpoint.NewState()
+	state.Stacks[testStackKey] = 5
+	if err := store.Save(context.Background(), state); err != nil {
+		t.Fatalf("failed to seed store: %v", err)
+	}
+
+	api := &mockAPI{
+		stacks: &client.ListStacksResponse{Stacks: []client.StackSummary{
+			{OrgName: testOrg, ProjectName: "my-project", StackName: "dev"},
+		}},
+		resources: map[string]*client.ResourceCountResponse{
+			testStackKey: {Count: 10, Version: 6},
+		},
+		updates: map[string]*client.ListUpdatesResponse{
+			testStackKey: {
+				Updates: []client.UpdateInfo{
+					{Kind: testUpdateKind, Result: testResultOK, StartTime: 2000, EndTime: 2120, Version: 6},
+					{Kind: testUpdateKind, Result: testResultOK, StartTime: 1000, EndTime: 1060, Version: 5},
+				},
+			},
+		},
+		deployments: map[string]*client.ListDeploymentsResponse{testOrg: {}},
+	}
+
+	c, reader := newTestCollector(t, api)
+	c.checkpoints = store
+	ctx := context.Background()
+
+	c.loadCheckpoints(ctx)
+	c.collect(ctx)
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatalf("failed to collect: %v", err)
+	}
+
+	// Only version 6 is newer than the restored checkpoint.
+	if got := sumInt64Counter(t, rm, "pulumi_update_total"); got != 1 {
+		t.Errorf("pulumi_update_total: got %d, want 1", got)
+	}
+
+	saved, err := store.Load(ctx)
+	if err != nil {
+		t.Fatalf("failed to load store: %v", err)
+	}
+	if saved.Stacks[testStackKey] != 6 {
+		t.Errorf("expected saved checkpoint 6, got %d", saved.Stacks[testStackKey])
+	}
+}
+
+// sumInt64Counter returns the sum of all data point values for the named int64 counter.
+func sumInt64Counter(t *testing.T, rm metricdata.ResourceMetrics, name string) int64 {
+	t.Helper()
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != name {
+				continue
+			}
+			s, ok := m.Data.(metricdata.Sum[int64])
+			if !ok {
+				t.Fatalf("metric %s is not an int64 sum", name)
+			}
+			var sum int64
+			for _, dp := range s.DataPoints {
+				sum += dp.Value
+			}
+			return sum
+		}
+	}
+	t.Fatalf("metric %s not found", name)
+	return 0
+}
+
+func TestListUpdatesPaging(t *testing.T) {
+	t.Parallel()
+
+	// 300 updates newest first (versions 305..6); the checkpoint is at version 5.
+	history := make([]client.UpdateInfo, 0, 300)
+	for v := 305; v > 5; v-- {
+		history = append(history, client.UpdateInfo{Kind: testUpdateKind, Result: testResultOK, Version: v})
+	}
+
+	tests := []struct {
+		name        string
+		maxPages    int
+		wantCounted int64
+		wantSkipped int64
+	}{
+		{"all pages fetched", 3, 300, 0},
+		{"page cap reached", 2, 200, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			api := &mockAPI{
+				resources: map[string]*client.ResourceCountResponse{testStackKey: {Count: 1}},
+				history:   map[string][]client.UpdateInfo{testStackKey: history},
+			}
+
+			c, reader := newTestCollector(t, api)
+			c.cfg.Pulumi.MaxUpdatePages = tt.maxPages
+			c.lastSeenVersion[testStackKey] = 5
+			ctx := context.Background()
+
+			if err := c.collectStack(ctx, client.StackSummary{OrgName: testOrg, ProjectName: "my-project", StackName: "dev"}); err != nil {
+				t.Fatalf("collectStack() error: %v", err)
+			}
+
+			var rm metricdata.ResourceMetrics
+			if err := reader.Collect(ctx, &rm); err != nil {
+				t.Fatalf("failed to collect: %v", err)
+			}
+
+			if got := sumInt64Counter(t, rm, "pulumi_update_total"); got != tt.wantCounted {
+				t.Errorf("pulumi_update_total: got %d, want %d", got, tt.wantCounted)
+			}
+			if tt.wantSkipped > 0 {
+				if got := sumInt64Counter(t, rm, "pulumi_update_skipped_total"); got != tt.wantSkipped {
+					t.Errorf("pulumi_update_skipped_total: got %d, want %d", got, tt.wantSkipped)
+				}
+			}
+			if c.lastSeenVersion[testStackKey] != 305 {
+				t.Errorf("expected lastSeenVersion=305, got %d", c.lastSeenVersion[testStackKey])
+			}
+		})
+	}
+}
+
 func TestCollectConcurrency(t *testing.T) {
 	t.Parallel()
 
@@ -386,11 +672,39 @@ func TestCollectConcurrency(t *testing.T) {
 		deployments: map[string]*client.ListDeploymentsResponse{testOrg: {Deployments: nil}},
 	}
 
-	c, _ := newTestCollector(t, api)
+	c, reader := newTestCollector(t, api)
 	ctx := context.Background()
 
 	// This should not deadlock with the semaphore.
 	c.collect(ctx)
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatalf("failed to collect: %v", err)
+	}
+
+	if got := sumInt64Counter(t, rm, "pulumi_exporter_stacks_total"); got != 20 {
+		t.Errorf("pulumi_exporter_stacks_total: got %d, want 20", got)
+	}
+
+	// Every family succeeded, so each reports a last successful collection.
+	families := make(map[string]bool)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != "pulumi_exporter_last_successful_collection_timestamp" {
+				continue
+			}
+			for _, dp := range m.Data.(metricdata.Gauge[float64]).DataPoints {
+				family, _ := dp.Attributes.Value("family")
+				families[family.AsString()] = true
+			}
+		}
+	}
+	for _, family := range []string{familyStacks, familyDeployments, familyTeams, familyNeo} {
+		if !families[family] {
+			t.Errorf("expected last successful collection for family %q", family)
+		}
+	}
 }
 
 func TestCollectTimeout(t *testing.T) {
@@ -411,7 +725,7 @@ func TestCollectTimeout(t *testing.T) {
 		},
 	}
 
-	c, err := NewCollector(api, cfg, meter, slog.Default())
+	c, err := NewCollector(api, checkpoint.NewMemoryStore(), cfg, meter, slog.Default())
 	if err != nil {
 		t.Fatalf("failed to create collector: %v", err)
 	}
@@ -457,7 +771,11 @@ func (m *slowMockAPI) ListUpdates(_ context.Context, _, _, _ string, _, _ int) (
 	return &client.ListUpdatesResponse{}, nil
 }
 
-func (m *slowMockAPI) ListOrgDeployments(_ context.Context, _ string) (*client.ListDeploymentsResponse, error) {
+func (m *slowMockAPI) ListOrgDeployments(_ context.Context, _ string, _ int) (*client.ListDeploymentsResponse, error) {
+	return &client.ListDeploymentsResponse{}, nil
+}
+
+func (m *slowMockAPI) ListStackDeployments(_ context.Context, _, _, _ string, _, _ int) (*client.ListDeploymentsResponse, error) {
 	return &client.ListDeploymentsResponse{}, nil
 }
 
@@ -477,12 +795,16 @@ func (m *slowMockAPI) ListPolicyGroups(_ context.Context, _ string) (*client.Lis
 	return &client.ListPolicyGroupsResponse{}, nil
 }
 
+func (m *slowMockAPI) GetPolicyGroup(_ context.Context, _, group string) (*client.PolicyGroupResponse, error) {
+	return &client.PolicyGroupResponse{Name: group}, nil
+}
+
 func (m *slowMockAPI) ListPolicyPacks(_ context.Context, _ string) (*client.ListPolicyPacksResponse, error) {
 	return &client.ListPolicyPacksResponse{}, nil
 }
 
-func (m *slowMockAPI) ListPolicyViolations(_ context.Context, _ string) (*client.ListPolicyViolationsResponse, error) {
-	return &client.ListPolicyViolationsResponse{}, nil
+func (m *slowMockAPI) ListPolicyViolations(_ context.Context, _ string, _ int) (*client.ListPolicyViolationsResponse, bool, error) {
+	return &client.ListPolicyViolationsResponse{}, false, nil
 }
 
 func (m *slowMockAPI) ListNeoTasks(_ context.Context, _ string) (*client.ListNeoTasksResponse, error) {
@@ -496,3 +818,1486 @@ func (m *slowMockAPI) GetOrgNeoTokenBudget(_ context.Context, _ string) (*client
 func (m *slowMockAPI) GetPolicyResultsMetadata(_ context.Context, _ string) (*client.PolicyResultsMetadataResponse, error) {
 	return &client.PolicyResultsMetadataResponse{}, nil
 }
+
+func TestReadiness(t *testing.T) {
+	t.Parallel()
+
+	api := &mockAPI{
+		stacks:      &client.ListStacksResponse{},
+		deployments: map[string]*client.ListDeploymentsResponse{testOrg: {}},
+	}
+
+	c, _ := newTestCollector(t, api)
+	c.cfg.Web.ReadyCycles = 2
+	ctx := context.Background()
+
+	if c.Ready() {
+		t.Fatal("expected not ready before the first collection")
+	}
+
+	// The first cycle only needs to succeed once, since fewer than 2 cycles have run.
+	c.collect(ctx)
+	if !c.Ready() {
+		t.Fatal("expected ready after the first successful collection")
+	}
+
+	api.stacksErr = errors.New("token expired")
+	c.collect(ctx)
+	if c.Ready() {
+		t.Fatal("expected not ready after ListStacks failed")
+	}
+
+	api.stacksErr = nil
+	c.collect(ctx)
+	if c.Ready() {
+		t.Fatal("expected not ready after a single successful cycle")
+	}
+
+	c.collect(ctx)
+	if !c.Ready() {
+		t.Fatal("expected ready after 2 consecutive successful cycles")
+	}
+}
+
+// blockingUpdatesAPI blocks ListUpdates until release is closed, after
+// signalling started, so a stacks cycle can be observed while it runs.
+type blockingUpdatesAPI struct {
+	*mockAPI
+	started chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func (m *blockingUpdatesAPI) ListUpdates(ctx context.Context, org, project, stack string, page, pageSize int) (*client.ListUpdatesResponse, error) {
+	m.once.Do(func() { close(m.started) })
+	<-m.release
+	return m.mockAPI.ListUpdates(ctx, org, project, stack, page, pageSize)
+}
+
+func TestNotReadyDuringFirstCycle(t *testing.T) {
+	t.Parallel()
+
+	api := &blockingUpdatesAPI{
+		mockAPI: &mockAPI{
+			stacks: &client.ListStacksResponse{Stacks: []client.StackSummary{
+				{OrgName: testOrg, ProjectName: "my-project", StackName: "dev"},
+			}},
+			resources: map[string]*client.ResourceCountResponse{testStackKey: {Count: 1}},
+			updates:   map[string]*client.ListUpdatesResponse{testStackKey: {}},
+		},
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+
+	c, _ := newTestCollector(t, api)
+	c.cfg.Web.ReadyCycles = 2
+
+	done := make(chan struct{})
+	go func() {
+		c.collect(context.Background())
+		close(done)
+	}()
+
+	// ListStacks has succeeded, but no stack has been collected yet.
+	<-api.started
+	if c.Ready() {
+		t.Error("expected not ready while the first cycle is running")
+	}
+
+	close(api.release)
+	<-done
+	if !c.Ready() {
+		t.Error("expected ready after the first cycle completed")
+	}
+}
+
+func TestStatusFamilies(t *testing.T) {
+	t.Parallel()
+
+	api := &mockAPI{
+		stacks:      &client.ListStacksResponse{},
+		deployments: map[string]*client.ListDeploymentsResponse{testOrg: {}},
+		teamsErr:    &client.APIError{Endpoint: "ListTeams", StatusCode: http.StatusForbidden},
+	}
+
+	c, _ := newTestCollector(t, api)
+	c.collect(context.Background())
+
+	st := c.Status()
+	if !st.Ready || !st.Healthy {
+		t.Errorf("expected ready and healthy, got %+v", st)
+	}
+	for _, family := range config.Families {
+		if st.Cycles[family].Count != 1 {
+			t.Errorf("expected 1 %s cycle, got %+v", family, st.Cycles[family])
+		}
+	}
+
+	families := st.Orgs[testOrg]
+	if families[familyStacks].LastSuccess.IsZero() {
+		t.Error("expected a last success for the stacks family")
+	}
+	if families[familyMembers].LastSuccess.IsZero() {
+		t.Error("expected a last success for the members family")
+	}
+
+	teams := families[familyTeams]
+	if !teams.Disabled || teams.LastError == "" || !teams.LastSuccess.IsZero() {
+		t.Errorf("expected the teams family to be disabled with an error, got %+v", teams)
+	}
+}
+
+func TestStatusTrackerHealthy(t *testing.T) {
+	t.Parallel()
+
+	s := newStatusTracker()
+	now := time.Now()
+
+	if !s.healthy(familyStacks, now, time.Minute, time.Minute) {
+		t.Error("expected healthy before the first cycle")
+	}
+
+	s.startCycle(familyStacks, now.Add(-2*time.Minute))
+	if s.healthy(familyStacks, now, time.Minute, time.Minute) {
+		t.Error("expected unhealthy when a cycle runs past its limit")
+	}
+
+	s.endCycle(familyStacks, now.Add(-90*time.Second))
+	if s.healthy(familyStacks, now, time.Minute, time.Minute) {
+		t.Error("expected unhealthy when no cycle started after the idle limit")
+	}
+	if !s.healthy(familyStacks, now, time.Minute, 2*time.Minute) {
+		t.Error("expected healthy within the idle limit")
+	}
+	if !s.healthy(familyTeams, now, time.Minute, time.Minute) {
+		t.Error("expected a family without cycles to be healthy")
+	}
+}
+
+// int64GaugeByLabel returns the data points of an int64 gauge keyed by the value of label.
+func int64GaugeByLabel(t *testing.T, rm metricdata.ResourceMetrics, name, label string) map[string]int64 {
+	t.Helper()
+	values := make(map[string]int64)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != name {
+				continue
+			}
+			g, ok := m.Data.(metricdata.Gauge[int64])
+			if !ok {
+				t.Fatalf("metric %s is not an int64 gauge", name)
+			}
+			for _, dp := range g.DataPoints {
+				v, _ := dp.Attributes.Value(attribute.Key(label))
+				values[v.AsString()] = dp.Value
+			}
+		}
+	}
+	return values
+}
+
+func TestDeletedStackStopsReporting(t *testing.T) {
+	t.Parallel()
+
+	api := &mockAPI{
+		stacks: &client.ListStacksResponse{Stacks: []client.StackSummary{
+			{OrgName: testOrg, ProjectName: "my-project", StackName: "dev"},
+			{OrgName: testOrg, ProjectName: "my-project", StackName: "prod"},
+		}},
+		resources: map[string]*client.ResourceCountResponse{
+			testStackKey:               {Count: 10, Version: 1},
+			"test-org/my-project/prod": {Count: 20, Version: 1},
+		},
+		updates: map[string]*client.ListUpdatesResponse{
+			testStackKey:               {},
+			"test-org/my-project/prod": {},
+		},
+		deployments: map[string]*client.ListDeploymentsResponse{testOrg: {}},
+	}
+
+	c, reader := newTestCollector(t, api)
+	ctx := context.Background()
+
+	c.collect(ctx)
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatalf("failed to collect: %v", err)
+	}
+	if got := int64GaugeByLabel(t, rm, "pulumi_stack_resource_count", "stack"); len(got) != 2 {
+		t.Fatalf("expected 2 stacks before deletion, got %v", got)
+	}
+
+	// prod is deleted.
+	api.stacks.Stacks = api.stacks.Stacks[:1]
+	c.collect(ctx)
+
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatalf("failed to collect: %v", err)
+	}
+	got := int64GaugeByLabel(t, rm, "pulumi_stack_resource_count", "stack")
+	if len(got) != 1 || got["dev"] != 10 {
+		t.Errorf("expected only dev=10 after deletion, got %v", got)
+	}
+}
+
+func TestZeroCountBucketsReported(t *testing.T) {
+	t.Parallel()
+
+	api := &mockAPI{
+		deployments: map[string]*client.ListDeploymentsResponse{
+			testOrg: {Deployments: []client.DeploymentInfo{
+				{ID: "1", Status: testStatusRun, Created: testCreatedAt},
+			}},
+		},
+	}
+
+	c, reader := newTestCollector(t, api)
+	ctx := context.Background()
+
+	if err := c.collectOrgDeployments(ctx, testOrg); err != nil {
+		t.Fatalf("collectOrgDeployments() error: %v", err)
+	}
+
+	// The running deployment finishes.
+	api.deployments[testOrg] = &client.ListDeploymentsResponse{Deployments: []client.DeploymentInfo{
+		{ID: "1", Status: testResultOK, Created: testCreatedAt},
+	}}
+	if err := c.collectOrgDeployments(ctx, testOrg); err != nil {
+		t.Fatalf("collectOrgDeployments() error: %v", err)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatalf("failed to collect: %v", err)
+	}
+
+	got := int64GaugeByLabel(t, rm, "pulumi_deployment_status", "status")
+	if got[testStatusRun] != 0 || got[testResultOK] != 1 {
+		t.Errorf("expected running=0 and succeeded=1, got %v", got)
+	}
+	if len(got) != len(deploymentStatuses) {
+		t.Errorf("expected all %d statuses reported, got %v", len(deploymentStatuses), got)
+	}
+}
+
+func TestStackFilter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	recent := now.Add(-time.Hour).Unix()
+	old := now.Add(-60 * 24 * time.Hour).Unix()
+
+	tests := []struct {
+		name   string
+		cfg    config.StackFilterConfig
+		stack  client.StackSummary
+		wanted bool
+	}{
+		{"no rules", config.StackFilterConfig{}, client.StackSummary{OrgName: testOrg, ProjectName: "app", StackName: "pr-1"}, true},
+		{"exclude glob", config.StackFilterConfig{Exclude: []string{"*/pr-*"}}, client.StackSummary{OrgName: testOrg, ProjectName: "app", StackName: "pr-1"}, false},
+		{"exclude other org", config.StackFilterConfig{Exclude: []string{"other/*/pr-*"}}, client.StackSummary{OrgName: testOrg, ProjectName: "app", StackName: "pr-1"}, true},
+		{"include miss", config.StackFilterConfig{Include: []string{"platform-*/*"}}, client.StackSummary{OrgName: testOrg, ProjectName: "app", StackName: "prod"}, false},
+		{"include hit", config.StackFilterConfig{Include: []string{"platform-*/*"}}, client.StackSummary{OrgName: testOrg, ProjectName: "platform-net", StackName: "prod"}, true},
+		{"exclude regex", config.StackFilterConfig{Exclude: []string{`re:.*/review-[0-9]+`}}, client.StackSummary{OrgName: testOrg, ProjectName: "app", StackName: "review-42"}, false},
+		{"max age recent", config.StackFilterConfig{MaxAge: 24 * time.Hour}, client.StackSummary{OrgName: testOrg, ProjectName: "app", StackName: "dev", LastUpdate: recent}, true},
+		{"max age old", config.StackFilterConfig{MaxAge: 24 * time.Hour}, client.StackSummary{OrgName: testOrg, ProjectName: "app", StackName: "dev", LastUpdate: old}, false},
+		{"max age never updated", config.StackFilterConfig{MaxAge: 24 * time.Hour}, client.StackSummary{OrgName: testOrg, ProjectName: "app", StackName: "dev"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			f, err := newStackFilter(tt.cfg)
+			if err != nil {
+				t.Fatalf("newStackFilter() error: %v", err)
+			}
+			if got := f.match(tt.stack, now); got != tt.wanted {
+				t.Errorf("match() = %v, want %v", got, tt.wanted)
+			}
+		})
+	}
+}
+
+func TestStackFilterInvalidPattern(t *testing.T) {
+	t.Parallel()
+
+	for _, p := range []string{"pr-*", "re:(", "a/[/b"} {
+		if _, err := newStackFilter(config.StackFilterConfig{Exclude: []string{p}}); err == nil {
+			t.Errorf("expected error for pattern %q", p)
+		}
+	}
+}
+
+func TestFilteredStacksNotCollected(t *testing.T) {
+	t.Parallel()
+
+	api := &mockAPI{
+		stacks: &client.ListStacksResponse{Stacks: []client.StackSummary{
+			{OrgName: testOrg, ProjectName: "my-project", StackName: "dev"},
+			{OrgName: testOrg, ProjectName: "my-project", StackName: "pr-7"},
+		}},
+		resources: map[string]*client.ResourceCountResponse{
+			testStackKey: {Count: 10, Version: 1},
+		},
+		updates: map[string]*client.ListUpdatesResponse{
+			testStackKey: {},
+		},
+		deployments: map[string]*client.ListDeploymentsResponse{testOrg: {}},
+	}
+
+	c, reader := newTestCollector(t, api)
+	filter, err := newStackFilter(config.StackFilterConfig{Exclude: []string{"*/pr-*"}})
+	if err != nil {
+		t.Fatalf("newStackFilter() error: %v", err)
+	}
+	c.filter = filter
+	ctx := context.Background()
+
+	c.collect(ctx)
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatalf("failed to collect: %v", err)
+	}
+
+	// The mock has no data for pr-7, so collecting it would fail the cycle.
+	outcomes := make(map[string]int64)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if s, ok := m.Data.(metricdata.Sum[int64]); ok && m.Name == "pulumi_exporter_stacks_total" {
+				for _, dp := range s.DataPoints {
+					v, _ := dp.Attributes.Value("outcome")
+					outcomes[v.AsString()] = dp.Value
+				}
+			}
+		}
+	}
+	if outcomes["processed"] != 1 || outcomes["filtered"] != 1 || outcomes["failed"] != 0 {
+		t.Errorf("expected 1 processed and 1 filtered stack, got %v", outcomes)
+	}
+}
+
+func TestDisabledFamilyNotCollected(t *testing.T) {
+	t.Parallel()
+
+	api := &mockAPI{
+		stacksErr:   errors.New("token expired"),
+		deployments: map[string]*client.ListDeploymentsResponse{testOrg: {}},
+	}
+
+	c, _ := newTestCollector(t, api)
+	c.cfg.Collectors.Stacks.Disabled = true
+	c.cfg.Collectors.Teams.Disabled = true
+	c.cfg.Collectors.Members.Interval = time.Hour
+
+	c.collect(context.Background())
+
+	if api.teamsCalls != 0 {
+		t.Errorf("expected no ListTeams calls for a disabled family, got %d", api.teamsCalls)
+	}
+
+	st := c.Status()
+	if _, ok := st.Cycles[familyStacks]; ok {
+		t.Errorf("expected no stacks cycle, got %+v", st.Cycles[familyStacks])
+	}
+	if st.Cycles[familyMembers].Count != 1 {
+		t.Errorf("expected 1 members cycle, got %+v", st.Cycles[familyMembers])
+	}
+	// Readiness does not depend on ListStacks with the stacks family disabled.
+	if !st.Ready {
+		t.Error("expected ready with the stacks family disabled")
+	}
+
+	if got := c.familyInterval(familyMembers); got != time.Hour {
+		t.Errorf("expected members interval 1h, got %v", got)
+	}
+	if got := c.familyTimeout(familyMembers); got != 54*time.Minute {
+		t.Errorf("expected members timeout 54m, got %v", got)
+	}
+	if got := c.familyTimeout(familyNeo); got != 10*time.Second {
+		t.Errorf("expected neo timeout to clamp to 10s, got %v", got)
+	}
+}
+
+func TestIncrementalSkipsUnchangedStacks(t *testing.T) {
+	t.Parallel()
+
+	api := &mockAPI{
+		stacks: &client.ListStacksResponse{Stacks: []client.StackSummary{
+			{OrgName: testOrg, ProjectName: "my-project", StackName: "dev", LastUpdate: 1000, ResourceCount: new(7)},
+			{OrgName: testOrg, ProjectName: "my-project", StackName: "staging", LastUpdate: 1000, ResourceCount: new(0)},
+			{OrgName: testOrg, ProjectName: "my-project", StackName: "prod", LastUpdate: 1000},
+		}},
+		resources: map[string]*client.ResourceCountResponse{
+			"test-org/my-project/prod": {Count: 20, Version: 1},
+		},
+		updates: map[string]*client.ListUpdatesResponse{
+			testStackKey:               {},
+			"test-org/my-project/prod": {},
+		},
+	}
+
+	c, reader := newTestCollector(t, api)
+	c.cfg.Pulumi.Incremental = true
+	ctx := context.Background()
+
+	// The first cycle collects every stack; the resource counts of dev and
+	// staging come from ListStacks, also when it is zero.
+	c.collectStackFamily(ctx)
+	if got := api.resourceCalls.Load(); got != 1 {
+		t.Errorf("expected 1 GetResourceCount call, got %d", got)
+	}
+	if got := api.updatesCalls.Load(); got != 3 {
+		t.Errorf("expected 3 ListUpdates calls, got %d", got)
+	}
+
+	// Only dev was updated since.
+	api.stacks.Stacks[0].LastUpdate = 2000
+	c.collectStackFamily(ctx)
+	if got := api.resourceCalls.Load(); got != 1 {
+		t.Errorf("expected no GetResourceCount call for unchanged stacks, got %d", got-1)
+	}
+	if got := api.updatesCalls.Load(); got != 4 {
+		t.Errorf("expected 1 ListUpdates call for the updated stack, got %d", got-3)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatalf("failed to collect: %v", err)
+	}
+	got := int64GaugeByLabel(t, rm, "pulumi_stack_resource_count", "stack")
+	if got["dev"] != 7 || got["staging"] != 0 || got["prod"] != 20 {
+		t.Errorf("expected dev=7, staging=0 and prod=20 from cache, got %v", got)
+	}
+	if n := sumInt64Counter(t, rm, "pulumi_exporter_stacks_total"); n != 6 {
+		t.Errorf("expected 6 stacks handled over 2 cycles, got %d", n)
+	}
+}
+
+func TestIncrementalCollectsStackDeployments(t *testing.T) {
+	t.Parallel()
+
+	api := &mockAPI{
+		stacks: &client.ListStacksResponse{Stacks: []client.StackSummary{
+			{OrgName: testOrg, ProjectName: "my-project", StackName: "dev", LastUpdate: 1000, ResourceCount: new(1)},
+		}},
+		stackDeployments: map[string]*client.ListDeploymentsResponse{
+			testStackKey: {Deployments: []client.DeploymentInfo{{ID: "1", Status: "running", Created: testCreatedAt}}},
+		},
+	}
+
+	c, reader := newTestCollector(t, api)
+	c.cfg.Pulumi.Incremental = true
+	c.deploymentStacks, _ = compileStackPatterns([]string{"my-project/*"})
+	ctx := context.Background()
+
+	c.collectStackFamily(ctx)
+
+	// The deployment finishes without a stack update.
+	api.stackDeployments[testStackKey].Deployments[0].Status = testResultOK
+	c.collectStackFamily(ctx)
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatalf("failed to collect: %v", err)
+	}
+	if got := int64GaugeByLabel(t, rm, "pulumi_stack_last_deployment_status", "status"); len(got) != 1 || got[testResultOK] != 1 {
+		t.Errorf("expected only succeeded=1, got %v", got)
+	}
+}
+
+func TestAdaptivePolling(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	old := now.Add(-400 * 24 * time.Hour).Unix()
+	api := &mockAPI{
+		stacks: &client.ListStacksResponse{Stacks: []client.StackSummary{
+			{OrgName: testOrg, ProjectName: "my-project", StackName: "active", LastUpdate: now.Add(-time.Hour).Unix()},
+			{OrgName: testOrg, ProjectName: "my-project", StackName: "running", LastUpdate: old},
+			{OrgName: testOrg, ProjectName: "my-project", StackName: "dormant", LastUpdate: old},
+			{OrgName: testOrg, ProjectName: "my-project", StackName: "never"},
+		}},
+		resources: map[string]*client.ResourceCountResponse{},
+		updates: map[string]*client.ListUpdatesResponse{
+			"test-org/my-project/running": {Updates: []client.UpdateInfo{
+				{Kind: testUpdateKind, Result: "in-progress", StartTime: old, Version: 3},
+			}},
+		},
+	}
+	for _, s := range api.stacks.Stacks {
+		api.resources[s.OrgName+"/"+s.ProjectName+"/"+s.StackName] = &client.ResourceCountResponse{Count: 1}
+	}
+
+	c, reader := newTestCollector(t, api)
+	c.cfg.Pulumi.Polling.Adaptive = true
+	ctx := context.Background()
+
+	// The first cycle collects every stack.
+	c.collectStackFamily(ctx)
+	if got := api.updatesCalls.Load(); got != 4 {
+		t.Fatalf("expected 4 ListUpdates calls, got %d", got)
+	}
+
+	// The next cycle only collects the active stack and the one with a running update.
+	c.collectStackFamily(ctx)
+	if got := api.updatesCalls.Load() - 4; got != 2 {
+		t.Errorf("expected 2 ListUpdates calls for due stacks, got %d", got)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatalf("failed to collect: %v", err)
+	}
+	tiers := int64GaugeByLabel(t, rm, "pulumi_exporter_poll_tier_stacks", "tier")
+	if tiers["active"] != 2 || tiers["recent"] != 0 || tiers["dormant"] != 2 {
+		t.Errorf("expected active=2, recent=0 and dormant=2, got %v", tiers)
+	}
+	// Deferred stacks keep reporting their gauges.
+	if got := int64GaugeByLabel(t, rm, "pulumi_stack_resource_count", "stack"); len(got) != 4 {
+		t.Errorf("expected 4 stacks reported, got %v", got)
+	}
+}
+
+// histogramCount returns the total number of observations of a float64 histogram.
+func histogramCount(t *testing.T, rm metricdata.ResourceMetrics, name string) uint64 {
+	t.Helper()
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != name {
+				continue
+			}
+			h, ok := m.Data.(metricdata.Histogram[float64])
+			if !ok {
+				t.Fatalf("metric %s is not a float64 histogram", name)
+			}
+			var count uint64
+			for _, dp := range h.DataPoints {
+				count += dp.Count
+			}
+			return count
+		}
+	}
+	t.Fatalf("metric %s not found", name)
+	return 0
+}
+
+func TestDeploymentHistory(t *testing.T) {
+	t.Parallel()
+
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var deployments []client.DeploymentInfo
+	for i := range 150 {
+		deployments = append(deployments, client.DeploymentInfo{
+			ID:          strconv.Itoa(i),
+			Status:      testResultOK,
+			Created:     "2024-01-01 00:00:00.000",
+			ProjectName: "my-project",
+			StackName:   "dev",
+			Operation:   testUpdateKind,
+			Initiator:   "github",
+			Jobs: []client.DeploymentJob{{
+				Status:      testResultOK,
+				Started:     created.Add(30 * time.Second),
+				LastUpdated: created.Add(90 * time.Second),
+			}},
+		})
+	}
+	deployments[0].Status = testStatusRun
+
+	api := &mockAPI{deployments: map[string]*client.ListDeploymentsResponse{
+		testOrg: {Deployments: deployments},
+	}}
+
+	c, reader := newTestCollector(t, api)
+	c.cfg.Pulumi.MaxDeploymentPages = 5
+	ctx := context.Background()
+
+	if err := c.collectOrgDeployments(ctx, testOrg); err != nil {
+		t.Fatalf("collectOrgDeployments() error: %v", err)
+	}
+	// The running deployment finishes; the others are not counted again.
+	deployments[0].Status = testResultOK
+	if err := c.collectOrgDeployments(ctx, testOrg); err != nil {
+		t.Fatalf("collectOrgDeployments() error: %v", err)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatalf("failed to collect: %v", err)
+	}
+	if got := sumInt64Counter(t, rm, "pulumi_deployment_total"); got != 150 {
+		t.Errorf("expected 150 finished deployments, got %d", got)
+	}
+	if got := histogramCount(t, rm, "pulumi_deployment_duration_seconds"); got != 150 {
+		t.Errorf("expected 150 deployment durations, got %d", got)
+	}
+	if got := histogramCount(t, rm, "pulumi_deployment_queue_seconds"); got != 150 {
+		t.Errorf("expected 150 deployment queue times, got %d", got)
+	}
+	if got := int64GaugeByLabel(t, rm, "pulumi_deployment_status", "status"); got[testResultOK] != 150 {
+		t.Errorf("expected 150 succeeded deployments, got %v", got)
+	}
+}
+
+func TestDeploymentsRestoredOnRestart(t *testing.T) {
+	t.Parallel()
+
+	deployment := func(id string) client.DeploymentInfo {
+		return client.DeploymentInfo{
+			ID: id, Status: testResultOK, Created: "2024-01-01 00:00:00.000",
+			ProjectName: "my-project", StackName: "dev", Operation: testUpdateKind, Initiator: "github",
+		}
+	}
+	api := &mockAPI{deployments: map[string]*client.ListDeploymentsResponse{
+		testOrg: {Deployments: []client.DeploymentInfo{deployment("2"), deployment("1")}},
+	}}
+	store := checkpoint.NewMemoryStore()
+	ctx := context.Background()
+
+	first, _ := newTestCollector(t, api)
+	first.checkpoints = store
+	if err := first.collectOrgDeployments(ctx, testOrg); err != nil {
+		t.Fatalf("collectOrgDeployments() error: %v", err)
+	}
+	first.saveCheckpoints(ctx)
+
+	// After a restart only the deployment that finished since is counted.
+	api.deployments[testOrg].Deployments = []client.DeploymentInfo{deployment("3"), deployment("2"), deployment("1")}
+	second, reader := newTestCollector(t, api)
+	second.checkpoints = store
+	second.loadCheckpoints(ctx)
+	if err := second.collectOrgDeployments(ctx, testOrg); err != nil {
+		t.Fatalf("collectOrgDeployments() error: %v", err)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatalf("failed to collect: %v", err)
+	}
+	if got := sumInt64Counter(t, rm, "pulumi_deployment_total"); got != 1 {
+		t.Errorf("expected 1 deployment counted after the restart, got %d", got)
+	}
+}
+
+func TestDeploymentSteps(t *testing.T) {
+	t.Parallel()
+
+	started := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	api := &mockAPI{deployments: map[string]*client.ListDeploymentsResponse{
+		testOrg: {Deployments: []client.DeploymentInfo{{
+			ID:      "1",
+			Status:  "failed",
+			Created: testCreatedAt,
+			Jobs: []client.DeploymentJob{{
+				Status:      "failed",
+				Started:     started,
+				LastUpdated: started.Add(5 * time.Minute),
+				Steps: []client.DeploymentStep{
+					{Name: "Install dependencies", Status: testResultOK, Started: started, LastUpdated: started.Add(time.Minute)},
+					{Name: "Pulumi up", Status: "failed", Started: started.Add(time.Minute), LastUpdated: started.Add(5 * time.Minute)},
+					{Name: "Cleanup", Status: "not-started"},
+				},
+			}},
+		}}},
+	}}
+
+	c, reader := newTestCollector(t, api)
+	ctx := context.Background()
+
+	if err := c.collectOrgDeployments(ctx, testOrg); err != nil {
+		t.Fatalf("collectOrgDeployments() error: %v", err)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatalf("failed to collect: %v", err)
+	}
+	if got := histogramCount(t, rm, "pulumi_deployment_step_duration_seconds"); got != 2 {
+		t.Errorf("expected 2 step durations, got %d", got)
+	}
+	if got := sumInt64Counter(t, rm, "pulumi_deployment_step_failures_total"); got != 1 {
+		t.Errorf("expected 1 failed step, got %d", got)
+	}
+}
+
+func TestStackDeployments(t *testing.T) {
+	t.Parallel()
+
+	api := &mockAPI{
+		resources: map[string]*client.ResourceCountResponse{
+			testStackKey:          {Count: 1},
+			"test-org/other/prod": {Count: 1},
+		},
+		stackDeployments: map[string]*client.ListDeploymentsResponse{
+			testStackKey: {Deployments: []client.DeploymentInfo{
+				{ID: "1", Status: "failed", Created: testCreatedAt, Paused: true},
+			}},
+		},
+	}
+
+	c, reader := newTestCollector(t, api)
+	c.deploymentStacks, _ = compileStackPatterns([]string{"my-project/*"})
+	ctx := context.Background()
+
+	for _, stack := range []client.StackSummary{
+		{OrgName: testOrg, ProjectName: "my-project", StackName: "dev"},
+		{OrgName: testOrg, ProjectName: "other", StackName: "prod"},
+	} {
+		if err := c.collectStack(ctx, stack); err != nil {
+			t.Fatalf("collectStack() error: %v", err)
+		}
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatalf("failed to collect: %v", err)
+	}
+	if got := int64GaugeByLabel(t, rm, "pulumi_stack_last_deployment_status", "status"); len(got) != 1 || got["failed"] != 1 {
+		t.Errorf("expected only failed=1, got %v", got)
+	}
+	if got := int64GaugeByLabel(t, rm, "pulumi_stack_deployments_paused", "stack"); len(got) != 1 || got["dev"] != 1 {
+		t.Errorf("expected only dev paused, got %v", got)
+	}
+}
+
+func TestStackDeploymentsPermissionDenied(t *testing.T) {
+	t.Parallel()
+
+	api := &mockAPI{
+		resources: map[string]*client.ResourceCountResponse{testStackKey: {Count: 1}},
+		deployments: map[string]*client.ListDeploymentsResponse{
+			testOrg: {Deployments: []client.DeploymentInfo{{ID: "1", Status: "running"}}, Total: 1},
+		},
+		stackDeployErr: fmt.Errorf("listing stack deployments: %w",
+			&client.APIError{Endpoint: "ListStackDeployments", StatusCode: http.StatusForbidden}),
+	}
+
+	c, reader := newTestCollector(t, api)
+	c.deploymentStacks, _ = compileStackPatterns([]string{"my-project/*"})
+	ctx := context.Background()
+
+	stack := client.StackSummary{OrgName: testOrg, ProjectName: "my-project", StackName: "dev"}
+	if err := c.collectStack(ctx, stack); err != nil {
+		t.Fatalf("collectStack() error: %v", err)
+	}
+	if c.collectsStackDeployments(stack) {
+		t.Error("expected per-stack deployments to stop after a 403")
+	}
+
+	// The org-level deployments family is not affected by the 403.
+	c.collectOrgFamily(ctx, testOrg, familyDeployments, c.collectOrgDeployments)
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatalf("failed to collect: %v", err)
+	}
+	if got := int64GaugeByLabel(t, rm, "pulumi_deployment_status", "status"); got["running"] != 1 {
+		t.Errorf("expected running=1, got %v", got)
+	}
+}
+
+func float64GaugeByLabel(t *testing.T, rm metricdata.ResourceMetrics, name, label string) map[string]float64 {
+	t.Helper()
+	values := make(map[string]float64)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != name {
+				continue
+			}
+			g, ok := m.Data.(metricdata.Gauge[float64])
+			if !ok {
+				t.Fatalf("metric %s is not a float64 gauge", name)
+			}
+			for _, dp := range g.DataPoints {
+				v, _ := dp.Attributes.Value(attribute.Key(label))
+				values[v.AsString()] = dp.Value
+			}
+		}
+	}
+	return values
+}
+
+func TestDoraMetrics(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	at := func(ago time.Duration) int64 { return now.Add(-ago).Unix() }
+
+	c, reader := newTestCollector(t, &mockAPI{})
+	c.history.merge(testStackKey, []client.UpdateInfo{
+		{Version: 1, Kind: testUpdateKind, Result: testResultOK, StartTime: at(10*24*time.Hour + 5*time.Minute), EndTime: at(10 * 24 * time.Hour)},
+		{Version: 2, Kind: "preview", Result: testResultOK, StartTime: at(3 * time.Hour), EndTime: at(3*time.Hour - time.Minute)},
+		{Version: 3, Kind: testUpdateKind, Result: testResultOK, StartTime: at(2 * time.Hour), EndTime: at(110 * time.Minute)},
+		{Version: 4, Kind: testUpdateKind, Result: "failed", StartTime: at(90 * time.Minute), EndTime: at(80 * time.Minute)},
+		{Version: 5, Kind: testUpdateKind, Result: "failed", StartTime: at(75 * time.Minute), EndTime: at(70 * time.Minute)},
+		{Version: 6, Kind: testUpdateKind, Result: testResultOK, StartTime: at(60 * time.Minute), EndTime: at(50 * time.Minute)},
+		{Version: 7, Kind: testUpdateKind, Result: "in-progress", StartTime: at(time.Minute)},
+	}, now)
+	c.recordStackHistories([]client.StackSummary{
+		{OrgName: testOrg, ProjectName: "my-project", StackName: "dev"},
+	}, now)
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("failed to collect: %v", err)
+	}
+
+	if got := int64GaugeByLabel(t, rm, "pulumi_dora_deployments", "window"); got["1d"] != 2 || got["7d"] != 2 || got["30d"] != 3 {
+		t.Errorf("deployment frequency = %v, want 1d=2 7d=2 30d=3", got)
+	}
+	if got := float64GaugeByLabel(t, rm, "pulumi_dora_change_failure_rate", "window"); got["1d"] != 0.5 || got["30d"] != 0.4 {
+		t.Errorf("change failure rate = %v, want 1d=0.5 30d=0.4", got)
+	}
+	if got := float64GaugeByLabel(t, rm, "pulumi_dora_time_to_restore_seconds", "window"); got["1d"] != 1800 {
+		t.Errorf("time to restore = %v, want 1d=1800", got)
+	}
+	// v3 starts with the preview 3h ago and ends 70m later, v6 has no preview.
+	if got := float64GaugeByLabel(t, rm, "pulumi_dora_lead_time_seconds", "window"); got["1d"] != 2400 {
+		t.Errorf("lead time = %v, want 1d=2400", got)
+	}
+
+	// The counts and sums behind the rates and means.
+	if got := int64GaugeByLabel(t, rm, "pulumi_dora_failed_deployments", "window"); got["1d"] != 2 || got["30d"] != 2 {
+		t.Errorf("failed deployments = %v, want 1d=2 30d=2", got)
+	}
+	if got := int64GaugeByLabel(t, rm, "pulumi_dora_restores", "window"); got["1d"] != 1 {
+		t.Errorf("restores = %v, want 1d=1", got)
+	}
+	if got := float64GaugeByLabel(t, rm, "pulumi_dora_restore_seconds_window_total", "window"); got["1d"] != 1800 {
+		t.Errorf("restore seconds = %v, want 1d=1800", got)
+	}
+	if got := int64GaugeByLabel(t, rm, "pulumi_dora_lead_time_deployments", "window"); got["1d"] != 2 {
+		t.Errorf("lead time deployments = %v, want 1d=2", got)
+	}
+	if got := float64GaugeByLabel(t, rm, "pulumi_dora_lead_time_seconds_window_total", "window"); got["1d"] != 4800 {
+		t.Errorf("lead time seconds = %v, want 1d=4800", got)
+	}
+
+	// Stacks no longer listed stop reporting.
+	c.recordStackHistories(nil, now)
+	rm = metricdata.ResourceMetrics{}
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("failed to collect: %v", err)
+	}
+	if got := int64GaugeByLabel(t, rm, "pulumi_dora_deployments", "window"); len(got) != 0 {
+		t.Errorf("expected no deployment frequency after the stack is gone, got %v", got)
+	}
+	if got := c.history.updates(testStackKey); len(got) != 0 {
+		t.Errorf("expected history to be pruned, got %d updates", len(got))
+	}
+}
+
+func TestStackUpdateResults(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	c, reader := newTestCollector(t, &mockAPI{})
+	c.history.merge(testStackKey, []client.UpdateInfo{
+		{Version: 1, Kind: testUpdateKind, Result: testResultOK, StartTime: 100, EndTime: 110},
+		{Version: 2, Kind: testUpdateKind, Result: "failed", StartTime: 200, EndTime: 210},
+		{Version: 3, Kind: "preview", Result: testResultOK, StartTime: 250, EndTime: 260},
+		{Version: 4, Kind: testUpdateKind, Result: "failed", StartTime: 300, EndTime: 310},
+		{Version: 5, Kind: testUpdateKind, Result: "in-progress", StartTime: 400},
+		{Version: 6, Kind: "destroy", Result: "failed", StartTime: 500, EndTime: 510},
+	}, now)
+	c.recordStackHistories([]client.StackSummary{
+		{OrgName: testOrg, ProjectName: "my-project", StackName: "dev"},
+	}, now)
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("failed to collect: %v", err)
+	}
+
+	if got := int64GaugeByLabel(t, rm, "pulumi_stack_last_update_result", "result"); len(got) != 2 || got["in-progress"] != 1 || got["failed"] != 1 {
+		t.Errorf("last update result = %v, want in-progress (update) and failed (destroy)", got)
+	}
+	if got := int64GaugeByLabel(t, rm, "pulumi_stack_consecutive_failures", "kind"); got[testUpdateKind] != 2 || got["destroy"] != 1 {
+		t.Errorf("consecutive failures = %v, want update=2 destroy=1", got)
+	}
+	if got := float64GaugeByLabel(t, rm, "pulumi_stack_last_attempted_update_timestamp", "kind"); got[testUpdateKind] != 400 || got["destroy"] != 500 {
+		t.Errorf("last attempted = %v, want update=400 destroy=500", got)
+	}
+	if got := float64GaugeByLabel(t, rm, "pulumi_stack_last_successful_update_timestamp", "kind"); len(got) != 1 || got[testUpdateKind] != 110 {
+		t.Errorf("last successful = %v, want only update=110", got)
+	}
+}
+
+func TestInProgressUpdatesCountedWhenFinished(t *testing.T) {
+	t.Parallel()
+
+	started := time.Now().Add(-2 * time.Hour).Unix()
+	api := &mockAPI{
+		resources: map[string]*client.ResourceCountResponse{testStackKey: {Count: 1}},
+		history: map[string][]client.UpdateInfo{testStackKey: {
+			{Kind: testUpdateKind, Result: "in-progress", Version: 2, StartTime: started},
+			{Kind: testUpdateKind, Result: testResultOK, Version: 1, StartTime: started - 60, EndTime: started - 30},
+		}},
+	}
+
+	c, reader := newTestCollector(t, api)
+	ctx := context.Background()
+	stack := client.StackSummary{OrgName: testOrg, ProjectName: "my-project", StackName: "dev"}
+
+	collect := func() metricdata.ResourceMetrics {
+		t.Helper()
+		if err := c.collectStack(ctx, stack); err != nil {
+			t.Fatalf("collectStack() error: %v", err)
+		}
+		c.recordStackHistories([]client.StackSummary{stack}, time.Now())
+		var rm metricdata.ResourceMetrics
+		if err := reader.Collect(ctx, &rm); err != nil {
+			t.Fatalf("failed to collect: %v", err)
+		}
+		return rm
+	}
+
+	rm := collect()
+	if got := sumInt64Counter(t, rm, "pulumi_update_total"); got != 1 {
+		t.Errorf("expected only the finished update counted, got %d", got)
+	}
+	if got := float64GaugeByLabel(t, rm, "pulumi_stack_update_in_progress_seconds", "kind"); got[testUpdateKind] < 7200 {
+		t.Errorf("expected in-progress age of at least 2h, got %v", got)
+	}
+
+	// The update finishes and a newer one follows.
+	api.history[testStackKey] = []client.UpdateInfo{
+		{Kind: testUpdateKind, Result: testResultOK, Version: 3, StartTime: started + 200, EndTime: started + 210},
+		{Kind: testUpdateKind, Result: "failed", Version: 2, StartTime: started, EndTime: started + 100},
+		{Kind: testUpdateKind, Result: testResultOK, Version: 1, StartTime: started - 60, EndTime: started - 30},
+	}
+	rm = collect()
+	if got := sumInt64Counter(t, rm, "pulumi_update_total"); got != 3 {
+		t.Errorf("expected the finished update counted once, got %d", got)
+	}
+	if got := histogramCount(t, rm, "pulumi_update_duration_seconds"); got != 3 {
+		t.Errorf("expected 3 durations recorded, got %d", got)
+	}
+	if got := float64GaugeByLabel(t, rm, "pulumi_stack_update_in_progress_seconds", "kind"); len(got) != 0 {
+		t.Errorf("expected no in-progress updates, got %v", got)
+	}
+
+	// Nothing is counted twice.
+	rm = collect()
+	if got := sumInt64Counter(t, rm, "pulumi_update_total"); got != 3 {
+		t.Errorf("expected no more updates counted, got %d", got)
+	}
+}
+
+func TestPendingUpdatesKeptWhileStackUnlisted(t *testing.T) {
+	t.Parallel()
+
+	started := time.Now().Add(-time.Hour).Unix()
+	api := &mockAPI{
+		resources: map[string]*client.ResourceCountResponse{testStackKey: {Count: 1}},
+		history: map[string][]client.UpdateInfo{testStackKey: {
+			{Kind: testUpdateKind, Result: "in-progress", Version: 2, StartTime: started},
+			{Kind: testUpdateKind, Result: "not-started", Version: 1},
+		}},
+	}
+
+	c, reader := newTestCollector(t, api)
+	ctx := context.Background()
+	stack := client.StackSummary{OrgName: testOrg, ProjectName: "my-project", StackName: "dev"}
+
+	if err := c.collectStack(ctx, stack); err != nil {
+		t.Fatalf("collectStack() error: %v", err)
+	}
+
+	// The stack is missing from one cycle while its update finishes.
+	c.pruneStackStates(map[string]struct{}{})
+	api.history[testStackKey][0] = client.UpdateInfo{
+		Kind: testUpdateKind, Result: testResultOK, Version: 2, StartTime: started, EndTime: started + 100,
+	}
+	if err := c.collectStack(ctx, stack); err != nil {
+		t.Fatalf("collectStack() error: %v", err)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatalf("failed to collect: %v", err)
+	}
+	if got := sumInt64Counter(t, rm, "pulumi_update_total"); got != 2 {
+		t.Errorf("expected the not-started and the finished update counted once, got %d", got)
+	}
+}
+
+func TestInProgressUpdatesRestoredOnRestart(t *testing.T) {
+	t.Parallel()
+
+	started := time.Now().Add(-time.Hour).Unix()
+	api := &mockAPI{
+		resources: map[string]*client.ResourceCountResponse{testStackKey: {Count: 1}},
+		history: map[string][]client.UpdateInfo{testStackKey: {
+			{Kind: "preview", Result: testResultOK, Version: 3, StartTime: started + 10, EndTime: started + 20},
+			{Kind: testUpdateKind, Result: "in-progress", Version: 2, StartTime: started},
+			{Kind: testUpdateKind, Result: testResultOK, Version: 1, StartTime: started - 60, EndTime: started - 30},
+		}},
+	}
+	store := checkpoint.NewMemoryStore()
+	ctx := context.Background()
+	stack := client.StackSummary{OrgName: testOrg, ProjectName: "my-project", StackName: "dev"}
+
+	first, _ := newTestCollector(t, api)
+	first.checkpoints = store
+	if err := first.collectStack(ctx, stack); err != nil {
+		t.Fatalf("collectStack() error: %v", err)
+	}
+	first.saveCheckpoints(ctx)
+
+	// The update finishes while the exporter restarts.
+	api.history[testStackKey][1] = client.UpdateInfo{
+		Kind: testUpdateKind, Result: testResultOK, Version: 2, StartTime: started, EndTime: started + 100,
+	}
+	second, reader := newTestCollector(t, api)
+	second.checkpoints = store
+	second.loadCheckpoints(ctx)
+	if err := second.collectStack(ctx, stack); err != nil {
+		t.Fatalf("collectStack() error: %v", err)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatalf("failed to collect: %v", err)
+	}
+	if got := sumInt64Counter(t, rm, "pulumi_update_total"); got != 1 {
+		t.Errorf("expected only the finished update counted after the restart, got %d", got)
+	}
+}
+
+func TestDriftMetrics(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	at := func(ago time.Duration) int64 { return now.Add(-ago).Unix() }
+
+	c, reader := newTestCollector(t, &mockAPI{})
+	c.history.merge(testStackKey, []client.UpdateInfo{
+		{Version: 1, Kind: "refresh", Result: testResultOK, EndTime: at(5 * time.Hour), ResourceChanges: map[string]int{"same": 5}},
+		{Version: 2, Kind: "refresh", Result: testResultOK, EndTime: at(4 * time.Hour), ResourceChanges: map[string]int{"same": 4, "update": 1}},
+		{Version: 3, Kind: testUpdateKind, Result: testResultOK, EndTime: at(3 * time.Hour), ResourceChanges: map[string]int{"create": 3}},
+		{Version: 4, Kind: "refresh", Result: "failed", EndTime: at(2 * time.Hour)},
+		{Version: 5, Kind: "preview", Result: testResultOK, EndTime: at(time.Hour), ResourceChanges: map[string]int{"same": 3, "update": 1, "delete": 1}},
+	}, now)
+	c.history.merge("test-org/my-project/prod", []client.UpdateInfo{
+		{Version: 1, Kind: testUpdateKind, Result: testResultOK, EndTime: at(time.Hour)},
+	}, now)
+	c.recordStackHistories([]client.StackSummary{
+		{OrgName: testOrg, ProjectName: "my-project", StackName: "dev"},
+		{OrgName: testOrg, ProjectName: "my-project", StackName: "prod"},
+	}, now)
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("failed to collect: %v", err)
+	}
+
+	if got := int64GaugeByLabel(t, rm, "pulumi_stack_drifted_resources", "stack"); len(got) != 1 || got["dev"] != 2 {
+		t.Errorf("drifted resources = %v, want only dev=2", got)
+	}
+	if got := float64GaugeByLabel(t, rm, "pulumi_stack_drift_check_age_seconds", "stack"); got["dev"] != 3600 {
+		t.Errorf("drift check age = %v, want dev=3600", got)
+	}
+	if got := float64GaugeByLabel(t, rm, "pulumi_stack_drift_age_seconds", "stack"); got["dev"] != 4*3600 {
+		t.Errorf("drift age = %v, want dev=%d", got, 4*3600)
+	}
+}
+
+func TestUpdateEnvironmentLabels(t *testing.T) {
+	t.Parallel()
+
+	ci := client.UpdateEnvironment{CLIVersion: "3.150.0", ExecKind: "cli", CISystem: "GitHub Actions"}
+	laptop := client.UpdateEnvironment{CLIVersion: "3.90.0", ExecKind: "cli"}
+	api := &mockAPI{
+		resources: map[string]*client.ResourceCountResponse{testStackKey: {Count: 1}},
+		history: map[string][]client.UpdateInfo{testStackKey: {
+			{Kind: "preview", Result: testResultOK, Version: 3, StartTime: 300, EndTime: 310, Environment: laptop},
+			{Kind: testUpdateKind, Result: testResultOK, Version: 2, StartTime: 200, EndTime: 210, Environment: ci},
+			{Kind: testUpdateKind, Result: testResultOK, Version: 1, StartTime: 100, EndTime: 110, Environment: laptop},
+		}},
+	}
+
+	c, reader := newTestCollector(t, api)
+	c.cfg.Pulumi.UpdateLabels = []string{config.UpdateLabelCISystem}
+	ctx := context.Background()
+	stack := client.StackSummary{OrgName: testOrg, ProjectName: "my-project", StackName: "dev"}
+
+	if err := c.collectStack(ctx, stack); err != nil {
+		t.Fatalf("collectStack() error: %v", err)
+	}
+	c.recordStackHistories([]client.StackSummary{stack}, time.Now())
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatalf("failed to collect: %v", err)
+	}
+
+	bySystem := make(map[string]int64)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != "pulumi_update_total" {
+				continue
+			}
+			for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+				if _, ok := dp.Attributes.Value("cli_version"); ok {
+					t.Errorf("unexpected cli_version label on %v", dp.Attributes)
+				}
+				v, _ := dp.Attributes.Value("ci_system")
+				bySystem[v.AsString()] += dp.Value
+			}
+		}
+	}
+	if bySystem["GitHub Actions"] != 1 || bySystem[""] != 2 {
+		t.Errorf("updates by ci_system = %v, want GitHub Actions=1 and 2 without", bySystem)
+	}
+
+	if got := int64GaugeByLabel(t, rm, "pulumi_stack_cli_version_info", "version"); len(got) != 1 || got["3.150.0"] != 1 {
+		t.Errorf("cli version info = %v, want only 3.150.0", got)
+	}
+}
+
+func TestViolationBreakdowns(t *testing.T) {
+	t.Parallel()
+
+	violation := func(pack, policy, stack, resourceType string) client.PolicyViolation {
+		return client.PolicyViolation{
+			ProjectName: "my-project", StackName: stack, PolicyPack: pack, PolicyName: policy,
+			ResourceType: resourceType, Level: "mandatory", Kind: "audit",
+		}
+	}
+	api := &mockAPI{violations: map[string]*client.ListPolicyViolationsResponse{
+		testOrg: {PolicyViolations: []client.PolicyViolation{
+			violation("aws", "s3-no-public-read", "dev", "aws:s3/bucket:Bucket"),
+			violation("aws", "s3-no-public-read", "prod", "aws:s3/bucket:Bucket"),
+			violation("aws", "s3-no-public-read", "prod", "aws:s3/bucket:Bucket"),
+			violation("aws", "ec2-tags", "prod", "aws:ec2/instance:Instance"),
+			violation("k8s", "no-latest-tag", "dev", "kubernetes:apps/v1:Deployment"),
+		}},
+	}}
+
+	c, reader := newTestCollector(t, api)
+	c.cfg.Pulumi.Violations = config.ViolationsConfig{TopPolicies: 2, TopStacks: 5}
+	c.cfg.Pulumi.MaxViolationPages = 10
+	ctx := context.Background()
+
+	if err := c.collectPolicyViolations(ctx, testOrg); err != nil {
+		t.Fatalf("collectPolicyViolations() error: %v", err)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatalf("failed to collect: %v", err)
+	}
+
+	// Ties are broken by name, so no-latest-tag falls into the other bucket.
+	if got := int64GaugeByLabel(t, rm, "pulumi_org_policy_violations_by_policy", "policy"); len(got) != 3 ||
+		got["s3-no-public-read"] != 3 || got["ec2-tags"] != 1 || got[otherBucket] != 1 {
+		t.Errorf("violations by policy = %v, want s3-no-public-read=3 ec2-tags=1 %s=1", got, otherBucket)
+	}
+	if got := int64GaugeByLabel(t, rm, "pulumi_org_policy_violations_by_stack", "stack"); len(got) != 2 || got["prod"] != 3 || got["dev"] != 2 {
+		t.Errorf("violations by stack = %v, want prod=3 dev=2", got)
+	}
+	if got := int64GaugeByLabel(t, rm, "pulumi_org_policy_violations_by_resource_type", "resource_type"); len(got) != 0 {
+		t.Errorf("expected no resource type breakdown by default, got %v", got)
+	}
+}
+
+func TestViolationLifecycle(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	violation := func(id, level string, observed time.Duration) client.PolicyViolation {
+		return client.PolicyViolation{ID: id, PolicyPack: "aws", Level: level, Kind: "audit", ObservedAt: now.Add(-observed)}
+	}
+
+	c, reader := newTestCollector(t, &mockAPI{})
+	ctx := context.Background()
+
+	// The first collection only records the open violations.
+	c.trackViolationLifecycle(ctx, testOrg, []client.PolicyViolation{
+		violation("a", "mandatory", 48*time.Hour),
+		violation("b", "mandatory", 2*time.Hour),
+		violation("c", "advisory", time.Hour),
+	}, true, now)
+
+	// a is resolved and d is new.
+	c.trackViolationLifecycle(ctx, testOrg, []client.PolicyViolation{
+		violation("b", "mandatory", 2*time.Hour),
+		violation("c", "advisory", time.Hour),
+		violation("d", "mandatory", time.Minute),
+	}, true, now)
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatalf("failed to collect: %v", err)
+	}
+
+	if got := sumInt64Counter(t, rm, "pulumi_org_policy_violations_opened_total"); got != 1 {
+		t.Errorf("expected 1 new violation, got %d", got)
+	}
+	if got := sumInt64Counter(t, rm, "pulumi_org_policy_violations_resolved_total"); got != 1 {
+		t.Errorf("expected 1 resolved violation, got %d", got)
+	}
+	if got := histogramCount(t, rm, "pulumi_org_policy_violation_remediation_seconds"); got != 1 {
+		t.Errorf("expected 1 time to remediate, got %d", got)
+	}
+	if got := float64GaugeByLabel(t, rm, "pulumi_org_policy_violation_oldest_age_seconds", "level"); got["mandatory"] != 7200 || got["advisory"] != 3600 {
+		t.Errorf("oldest violation age = %v, want mandatory=7200 advisory=3600", got)
+	}
+}
+
+func TestPolicyViolationPaging(t *testing.T) {
+	t.Parallel()
+
+	violations := make([]client.PolicyViolation, 5)
+	for i := range violations {
+		violations[i] = client.PolicyViolation{ID: strconv.Itoa(i), PolicyPack: "aws", Level: "mandatory", Kind: "audit"}
+	}
+	api := &mockAPI{violations: map[string]*client.ListPolicyViolationsResponse{
+		testOrg: {PolicyViolations: violations},
+	}}
+
+	c, reader := newTestCollector(t, api)
+	ctx := context.Background()
+
+	collect := func(maxPages int) metricdata.ResourceMetrics {
+		t.Helper()
+		c.cfg.Pulumi.MaxViolationPages = maxPages
+		if err := c.collectPolicyViolations(ctx, testOrg); err != nil {
+			t.Fatalf("collectPolicyViolations() error: %v", err)
+		}
+		var rm metricdata.ResourceMetrics
+		if err := reader.Collect(ctx, &rm); err != nil {
+			t.Fatalf("failed to collect: %v", err)
+		}
+		return rm
+	}
+
+	// All three pages of two are fetched.
+	rm := collect(3)
+	if got := sumInt64Gauge(t, rm, "pulumi_org_policy_violations"); got != 5 {
+		t.Errorf("expected 5 violations, got %d", got)
+	}
+	if got := sumInt64Gauge(t, rm, "pulumi_org_policy_violations_truncated"); got != 0 {
+		t.Errorf("expected no truncation, got %d", got)
+	}
+
+	// The cap cuts off the last page, which must not resolve its violation.
+	rm = collect(2)
+	if got := sumInt64Gauge(t, rm, "pulumi_org_policy_violations"); got != 4 {
+		t.Errorf("expected 4 violations, got %d", got)
+	}
+	if got := sumInt64Gauge(t, rm, "pulumi_org_policy_violations_truncated"); got != 1 {
+		t.Errorf("expected truncation, got %d", got)
+	}
+	c.mu.Lock()
+	open := len(c.openViolations[testOrg])
+	c.mu.Unlock()
+	if open != 5 {
+		t.Errorf("expected 5 open violations, got %d", open)
+	}
+}
+
+func TestPolicyGroupCoverage(t *testing.T) {
+	t.Parallel()
+
+	stack := func(org, project, name string) client.StackSummary {
+		return client.StackSummary{OrgName: org, ProjectName: project, StackName: name}
+	}
+	api := &mockAPI{
+		stacks: &client.ListStacksResponse{Stacks: []client.StackSummary{
+			stack(testOrg, "app", "dev"),
+			stack(testOrg, "app", "prod"),
+			stack(testOrg, "web", "dev"),
+			stack(testOrg, "legacy", "dev"),
+			stack("other-org", "app", "dev"),
+		}},
+		policyGroups: map[string]*client.ListPolicyGroupsResponse{
+			testOrg: {PolicyGroups: []client.PolicyGroupInfo{
+				{Name: "default-policy-group", NumStacks: 3, NumEnabledPolicyPacks: 1, IsOrgDefault: true},
+				{Name: "production", NumStacks: 1, NumEnabledPolicyPacks: 2},
+			}},
+		},
+		policyGroup: map[string]*client.PolicyGroupResponse{
+			testOrg + "/production": {Name: "production", Stacks: []client.PolicyGroupStack{
+				{ProjectName: "app", StackName: "prod"},
+			}},
+		},
+	}
+
+	c, reader := newTestCollector(t, api)
+	// Filtered stacks are not counted as ungoverned.
+	c.filter, _ = newStackFilter(config.StackFilterConfig{Exclude: []string{testOrg + "/legacy/*"}})
+	ctx := context.Background()
+
+	if err := c.collectPolicies(ctx, testOrg); err != nil {
+		t.Fatalf("collectPolicies() error: %v", err)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatalf("failed to collect: %v", err)
+	}
+
+	if got := int64GaugeByLabel(t, rm, "pulumi_org_policy_group_stacks", "policy_group"); got["default-policy-group"] != 3 || got["production"] != 1 {
+		t.Errorf("policy group stacks = %v, want default-policy-group=3 production=1", got)
+	}
+	if got := int64GaugeByLabel(t, rm, "pulumi_org_policy_group_enabled_policy_packs", "policy_group"); got["default-policy-group"] != 1 || got["production"] != 2 {
+		t.Errorf("policy group packs = %v, want default-policy-group=1 production=2", got)
+	}
+
+	// Stacks only in the default group are not governed.
+	if got := sumInt64Gauge(t, rm, "pulumi_org_policy_ungoverned_stacks"); got != 2 {
+		t.Errorf("expected 2 ungoverned stacks, got %d", got)
+	}
+	if got := int64GaugeByLabel(t, rm, "pulumi_org_policy_ungoverned_project_stacks", "project"); len(got) != 2 || got["app"] != 1 || got["web"] != 1 {
+		t.Errorf("ungoverned stacks by project = %v, want app=1 web=1", got)
+	}
+}
+
+func TestRequiredPolicyPacks(t *testing.T) {
+	t.Parallel()
+
+	stack := func(project, name string) client.StackSummary {
+		return client.StackSummary{OrgName: testOrg, ProjectName: project, StackName: name}
+	}
+	api := &mockAPI{
+		stacks: &client.ListStacksResponse{Stacks: []client.StackSummary{
+			stack("app", "dev"),
+			stack("app", "prod"),
+			stack("web", "prod"),
+		}},
+		policyGroups: map[string]*client.ListPolicyGroupsResponse{
+			testOrg: {PolicyGroups: []client.PolicyGroupInfo{
+				{Name: "default-policy-group", NumStacks: 3, NumEnabledPolicyPacks: 1, IsOrgDefault: true},
+				{Name: "production", NumStacks: 1, NumEnabledPolicyPacks: 1},
+			}},
+		},
+		policyGroup: map[string]*client.PolicyGroupResponse{
+			testOrg + "/default-policy-group": {
+				Name: "default-policy-group", IsOrgDefault: true,
+				Stacks: []client.PolicyGroupStack{
+					{ProjectName: "app", StackName: "dev"},
+					{ProjectName: "app", StackName: "prod"},
+					{ProjectName: "web", StackName: "prod"},
+				},
+				AppliedPolicyPacks: []client.AppliedPolicyPack{{Name: "baseline", Version: 1}},
+			},
+			testOrg + "/production": {
+				Name:               "production",
+				Stacks:             []client.PolicyGroupStack{{ProjectName: "app", StackName: "prod"}},
+				AppliedPolicyPacks: []client.AppliedPolicyPack{{Name: "aws-security", Version: 2}},
+			},
+		},
+		policyPacks: map[string]*client.ListPolicyPacksResponse{
+			testOrg: {PolicyPacks: []client.PolicyPackInfo{
+				{Name: "aws-security", Versions: []int64{1, 2, 3}},
+				{Name: "baseline", Versions: []int64{1}},
+			}},
+		},
+	}
+
+	c, reader := newTestCollector(t, api)
+	requiredPacks, err := compileRequiredPolicyPacks([]config.RequiredPolicyPack{
+		{Name: "aws-security", Stacks: []string{"*/prod"}},
+		{Name: "aws-security", Orgs: []string{testOrg}, Stacks: []string{"web/*"}},
+		{Name: "baseline"},
+		{Name: "k8s-security", Orgs: []string{"other-org"}},
+	})
+	if err != nil {
+		t.Fatalf("compileRequiredPolicyPacks() error: %v", err)
+	}
+	c.requiredPacks = requiredPacks
+	ctx := context.Background()
+
+	if err := c.collectPolicies(ctx, testOrg); err != nil {
+		t.Fatalf("collectPolicies() error: %v", err)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatalf("failed to collect: %v", err)
+	}
+
+	// Packs required in other orgs are not reported.
+	if got := int64GaugeByLabel(t, rm, "pulumi_org_policy_required_pack_missing_stacks", "policy_pack"); len(got) != 2 ||
+		got["aws-security"] != 1 || got["baseline"] != 0 {
+		t.Errorf("missing stacks = %v, want aws-security=1 baseline=0", got)
+	}
+	if got := int64GaugeByLabel(t, rm, "pulumi_stack_required_policy_pack_missing", "project"); len(got) != 1 || got["web"] != 1 {
+		t.Errorf("stacks missing packs = %v, want web=1", got)
+	}
+	if got := int64GaugeByLabel(t, rm, "pulumi_org_policy_required_pack_versions_behind", "policy_group"); len(got) != 2 ||
+		got["production"] != 1 || got["default-policy-group"] != 0 {
+		t.Errorf("versions behind = %v, want production=1 default-policy-group=0", got)
+	}
+
+	// The default group does not count towards coverage.
+	if got := sumInt64Gauge(t, rm, "pulumi_org_policy_ungoverned_stacks"); got != 2 {
+		t.Errorf("expected 2 ungoverned stacks, got %d", got)
+	}
+
+	// Without the published versions only the version lag is not reported.
+	api.policyPacksErr = errors.New("listing policy packs: unavailable")
+	if err := c.collectPolicies(ctx, testOrg); err == nil {
+		t.Fatal("expected collectPolicies() to fail")
+	}
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatalf("failed to collect: %v", err)
+	}
+	if got := int64GaugeByLabel(t, rm, "pulumi_stack_required_policy_pack_missing", "project"); len(got) != 1 || got["web"] != 1 {
+		t.Errorf("stacks missing packs = %v, want web=1", got)
+	}
+	if got := int64GaugeByLabel(t, rm, "pulumi_org_policy_required_pack_versions_behind", "policy_group"); len(got) != 0 {
+		t.Errorf("versions behind = %v, want none", got)
+	}
+}
+
+func TestRequiredPolicyPackInDefaultGroup(t *testing.T) {
+	t.Parallel()
+
+	// The default group lists none of the stacks, but applies to all of them.
+	api := &mockAPI{
+		stacks: &client.ListStacksResponse{Stacks: []client.StackSummary{
+			{OrgName: testOrg, ProjectName: "app", StackName: "dev"},
+			{OrgName: testOrg, ProjectName: "app", StackName: "prod"},
+		}},
+		policyGroups: map[string]*client.ListPolicyGroupsResponse{
+			testOrg: {PolicyGroups: []client.PolicyGroupInfo{
+				{Name: "default-policy-group", NumEnabledPolicyPacks: 1, IsOrgDefault: true},
+			}},
+		},
+		policyGroup: map[string]*client.PolicyGroupResponse{
+			testOrg + "/default-policy-group": {
+				Name: "default-policy-group", IsOrgDefault: true,
+				AppliedPolicyPacks: []client.AppliedPolicyPack{{Name: "baseline", Version: 1}},
+			},
+		},
+	}
+
+	c, reader := newTestCollector(t, api)
+	requiredPacks, err := compileRequiredPolicyPacks([]config.RequiredPolicyPack{{Name: "baseline"}})
+	if err != nil {
+		t.Fatalf("compileRequiredPolicyPacks() error: %v", err)
+	}
+	c.requiredPacks = requiredPacks
+	ctx := context.Background()
+
+	if err := c.collectPolicies(ctx, testOrg); err != nil {
+		t.Fatalf("collectPolicies() error: %v", err)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(ctx, &rm); err != nil {
+		t.Fatalf("failed to collect: %v", err)
+	}
+	if got := int64GaugeByLabel(t, rm, "pulumi_org_policy_required_pack_missing_stacks", "policy_pack"); len(got) != 1 || got["baseline"] != 0 {
+		t.Errorf("missing stacks = %v, want baseline=0", got)
+	}
+	if got := int64GaugeByLabel(t, rm, "pulumi_stack_required_policy_pack_missing", "stack"); len(got) != 0 {
+		t.Errorf("expected no stacks missing packs, got %v", got)
+	}
+}
@@ -0,0 +1,93 @@
+package collector
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/pulumi-labs/pulumi-exporter/internal/client"
+)
+
+// getPolicyGroups fetches the stacks and enabled packs of the policy groups
+// of org that have stacks. The org default group applies to every stack but
+// does not count towards coverage, so it is only fetched when a required
+// policy pack applies to org.
+func (c *Collector) getPolicyGroups(ctx context.Context, org string, groups []client.PolicyGroupInfo) ([]*client.PolicyGroupResponse, error) {
+	withDefault := len(c.requiredPolicyPacks(org)) > 0
+
+	var details []*client.PolicyGroupResponse
+	for _, g := range groups {
+		if g.IsOrgDefault && !withDefault || !g.IsOrgDefault && g.NumStacks == 0 {
+			continue
+		}
+		group, err := c.client.GetPolicyGroup(ctx, org, g.Name)
+		if err != nil {
+			return nil, err
+		}
+		details = append(details, group)
+	}
+	return details, nil
+}
+
+// recordPolicyCoverage reports the stacks of org that are not in any policy
+// group other than the org default, in total and by project.
+func (c *Collector) recordPolicyCoverage(org string, groups []*client.PolicyGroupResponse, stacks []client.StackSummary) {
+	governed := make(map[[2]string]struct{})
+	for _, g := range groups {
+		if g.IsOrgDefault {
+			continue
+		}
+		for _, s := range g.Stacks {
+			governed[[2]string{s.ProjectName, s.StackName}] = struct{}{}
+		}
+	}
+
+	// Every project is reported, as 0 when all its stacks are governed.
+	byProject := make(map[string]int64)
+	var total int64
+	for _, s := range stacks {
+		byProject[s.ProjectName] += 0
+		if _, ok := governed[[2]string{s.ProjectName, s.StackName}]; ok {
+			continue
+		}
+		byProject[s.ProjectName]++
+		total++
+	}
+
+	var gauges gaugeBatch
+	gauges.addInt64(c.instruments.orgUngovernedStacks, total, orgAttr(org))
+	for project, count := range byProject {
+		gauges.addInt64(c.instruments.projectUngovernedStacks, count,
+			attribute.String("org", org),
+			attribute.String("project", project),
+		)
+	}
+	c.instruments.gauges.replace(orgScope(org, "policy_coverage"), &gauges)
+}
+
+// orgStacks returns the stacks of org that pass the stack filter, from the
+// latest ListStacks call of the stacks family, or calls ListStacks when the
+// stacks family has not listed any, e.g. because it is disabled.
+func (c *Collector) orgStacks(ctx context.Context, org string) ([]client.StackSummary, error) {
+	c.mu.Lock()
+	stacks := c.lastStacks
+	c.mu.Unlock()
+
+	if stacks == nil {
+		resp, err := c.client.ListStacks(ctx)
+		if err != nil {
+			return nil, err
+		}
+		stacks = resp.Stacks
+	}
+
+	now := time.Now()
+	var orgStacks []client.StackSummary
+	for _, s := range stacks {
+		if s.OrgName == org && c.filter.match(s, now) {
+			orgStacks = append(orgStacks, s)
+		}
+	}
+	return orgStacks, nil
+}
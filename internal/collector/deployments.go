@@ -2,28 +2,228 @@ package collector
 
 import (
 	"context"
+	"time"
 
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
+
+	"github.com/pulumi-labs/pulumi-exporter/internal/client"
+	"github.com/pulumi-labs/pulumi-exporter/internal/config"
 )
 
-func (c *Collector) collectOrgDeployments(ctx context.Context, org string) {
-	resp, err := c.client.ListOrgDeployments(ctx, org)
+// deploymentStatuses are the deployment statuses always reported, as 0 when
+// an org has no deployments in that status.
+var deploymentStatuses = []string{"not-started", "accepted", "running", "failed", "succeeded", "skipped"}
+
+// deploymentTimeLayouts are the formats Pulumi Cloud uses for deployment timestamps.
+var deploymentTimeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999"}
+
+func (c *Collector) collectOrgDeployments(ctx context.Context, org string) error {
+	resp, err := c.client.ListOrgDeployments(ctx, org, max(c.cfg.Pulumi.MaxDeploymentPages, 1))
 	if err != nil {
-		c.logger.Error("failed to list org deployments", "org", org, "error", err)
-		return
+		return err
 	}
 
-	// Count deployments by status.
+	// Count the fetched deployments by status.
 	statusCounts := make(map[string]int64)
 	for _, d := range resp.Deployments {
 		statusCounts[d.Status]++
 	}
 
-	for status, count := range statusCounts {
-		c.instruments.deploymentStatus.Record(ctx, count, metric.WithAttributes(
+	var gauges gaugeBatch
+	for status, count := range countBuckets(statusCounts, deploymentStatuses...) {
+		gauges.addInt64(c.instruments.deploymentStatus, count,
 			attribute.String("org", org),
 			attribute.String("status", status),
-		))
+		)
+	}
+	c.instruments.gauges.replace(orgScope(org, familyDeployments), &gauges)
+
+	c.recordFinishedDeployments(ctx, org, resp.Deployments)
+
+	return nil
+}
+
+// recordFinishedDeployments records the counters and histograms of every
+// finished deployment that was not recorded in a previous cycle. Deployments
+// of an org seen for the first time follow the checkpoint seed policy.
+func (c *Collector) recordFinishedDeployments(ctx context.Context, org string, deployments []client.DeploymentInfo) {
+	c.mu.Lock()
+	recorded, seen := c.recordedDeployments[org]
+	c.mu.Unlock()
+
+	countHistory := seen || c.cfg.Checkpoint.SeedPolicy != config.SeedPolicyLatest
+
+	// Only deployments still listed are kept, so the set does not grow
+	// beyond the fetched pages.
+	next := make(map[string]struct{}, len(deployments))
+	for _, d := range deployments {
+		if !deploymentFinished(d.Status) {
+			continue
+		}
+		next[d.ID] = struct{}{}
+		if _, ok := recorded[d.ID]; ok || !countHistory {
+			continue
+		}
+		c.recordDeployment(ctx, org, d)
+	}
+
+	c.mu.Lock()
+	c.recordedDeployments[org] = next
+	c.mu.Unlock()
+}
+
+// recordDeployment records one finished deployment.
+func (c *Collector) recordDeployment(ctx context.Context, org string, d client.DeploymentInfo) {
+	c.instruments.deploymentTotal.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("org", org),
+		attribute.String("project", d.ProjectName),
+		attribute.String("stack", d.StackName),
+		attribute.String("operation", d.Operation),
+		attribute.String("initiator", d.Initiator),
+		attribute.String("agent_pool", d.AgentPool),
+		attribute.String("status", d.Status),
+	))
+
+	c.recordDeploymentSteps(ctx, org, d)
+
+	created, ok := parseDeploymentTime(d.Created)
+	if !ok {
+		return
+	}
+	started, ended := deploymentJobTimes(d)
+	if started.IsZero() {
+		return
+	}
+
+	stackAttrs := []attribute.KeyValue{
+		attribute.String("org", org),
+		attribute.String("project", d.ProjectName),
+		attribute.String("stack", d.StackName),
+		attribute.String("operation", d.Operation),
+	}
+	if queue := started.Sub(created); queue >= 0 {
+		c.instruments.deploymentQueueTime.Record(ctx, queue.Seconds(), metric.WithAttributes(stackAttrs...))
+	}
+	if !ended.IsZero() && !ended.Before(started) {
+		c.instruments.deploymentDuration.Record(ctx, ended.Sub(started).Seconds(),
+			metric.WithAttributes(append(stackAttrs, attribute.String("status", d.Status))...))
+	}
+}
+
+// recordDeploymentSteps records the duration of every step of d that ran, and
+// counts the failed ones.
+func (c *Collector) recordDeploymentSteps(ctx context.Context, org string, d client.DeploymentInfo) {
+	for _, j := range d.Jobs {
+		for _, s := range j.Steps {
+			if s.Status == "failed" {
+				c.instruments.deploymentStepFailed.Add(ctx, 1, metric.WithAttributes(
+					attribute.String("org", org),
+					attribute.String("step", s.Name),
+				))
+			}
+			if s.Started.IsZero() || s.LastUpdated.Before(s.Started) {
+				continue
+			}
+			c.instruments.deploymentStepTime.Record(ctx, s.LastUpdated.Sub(s.Started).Seconds(), metric.WithAttributes(
+				attribute.String("org", org),
+				attribute.String("step", s.Name),
+				attribute.String("status", s.Status),
+			))
+		}
+	}
+}
+
+// collectsStackDeployments reports whether the latest deployment of stack is
+// collected: it matches --pulumi.deployment-stacks, the deployments family is
+// enabled for its org and per-stack lookups were not refused for its org.
+func (c *Collector) collectsStackDeployments(stack client.StackSummary) bool {
+	if len(c.deploymentStacks) == 0 || c.familyDisabled(familyDeployments) || c.familyDenied(stack.OrgName, familyDeployments) {
+		return false
+	}
+	c.mu.Lock()
+	_, denied := c.deniedStackDeployments[stack.OrgName]
+	c.mu.Unlock()
+	if denied {
+		return false
+	}
+	return matchAny(c.deploymentStacks, stack.OrgName+"/"+stack.ProjectName+"/"+stack.StackName)
+}
+
+// denyStackDeployments stops the per-stack deployment lookups for org after
+// the API refused access with err. The org-level deployments family is not
+// affected.
+func (c *Collector) denyStackDeployments(org string, err error) {
+	c.mu.Lock()
+	c.deniedStackDeployments[org] = struct{}{}
+	c.mu.Unlock()
+	c.logger.Warn("access token lacks permission, disabling per-stack deployments",
+		"org", org, "status", client.StatusCode(err), "error", err)
+}
+
+// collectStackDeployment adds the status, time and paused state of the latest
+// deployment of stack to gauges. Stacks without deployments report nothing.
+func (c *Collector) collectStackDeployment(ctx context.Context, stack client.StackSummary, gauges *gaugeBatch, stackAttrs []attribute.KeyValue) error {
+	resp, err := c.client.ListStackDeployments(ctx, stack.OrgName, stack.ProjectName, stack.StackName, 1, 1)
+	if err != nil {
+		if client.IsPermissionDenied(err) {
+			c.denyStackDeployments(stack.OrgName, err)
+			return nil
+		}
+		return err
+	}
+	if len(resp.Deployments) == 0 {
+		return nil
+	}
+
+	d := resp.Deployments[0]
+	gauges.addInt64(c.instruments.stackDeploymentStatus, 1, append(stackAttrs, attribute.String("status", d.Status))...)
+	if created, ok := parseDeploymentTime(d.Created); ok {
+		gauges.addFloat64(c.instruments.stackDeploymentTime, float64(created.Unix()), stackAttrs...)
+	}
+	var paused int64
+	if d.Paused {
+		paused = 1
+	}
+	gauges.addInt64(c.instruments.stackDeploymentPaused, paused, stackAttrs...)
+
+	return nil
+}
+
+// deploymentFinished reports whether a deployment in status will not change anymore.
+func deploymentFinished(status string) bool {
+	switch status {
+	case "failed", "succeeded", "skipped":
+		return true
+	default:
+		return false
+	}
+}
+
+// deploymentJobTimes returns when the first job of d started and the last one
+// was last updated, or zero times if d has no started jobs.
+func deploymentJobTimes(d client.DeploymentInfo) (started, ended time.Time) {
+	for _, j := range d.Jobs {
+		if j.Started.IsZero() {
+			continue
+		}
+		if started.IsZero() || j.Started.Before(started) {
+			started = j.Started
+		}
+		if j.LastUpdated.After(ended) {
+			ended = j.LastUpdated
+		}
+	}
+	return started, ended
+}
+
+// parseDeploymentTime parses a deployment timestamp in any of the formats
+// Pulumi Cloud returns.
+func parseDeploymentTime(s string) (time.Time, bool) {
+	for _, layout := range deploymentTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
 	}
+	return time.Time{}, false
 }
@@ -0,0 +1,111 @@
+package collector
+
+import (
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/pulumi-labs/pulumi-exporter/internal/client"
+)
+
+// doraWindows are the rolling windows the DORA metrics are reported over.
+var doraWindows = []struct {
+	name string
+	size time.Duration
+}{
+	{"1d", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+	{"30d", 30 * 24 * time.Hour},
+}
+
+// doraStats holds the DORA metrics of one stack over one window. Only
+// updates of kind "update" are deployments; previews mark the start of a
+// change for the lead time.
+type doraStats struct {
+	deployments  int     // successful updates
+	failures     int     // failed updates
+	restores     int     // successful updates that ended a failure streak
+	restoreTotal float64 // seconds from the first failure of a streak to its restore
+	leads        int     // successful updates with a lead time
+	leadTotal    float64 // seconds from the first preview since the previous success to the end of the update
+}
+
+// computeDora computes the DORA metrics of updates, oldest first, over the
+// updates that ended at or after since.
+func computeDora(updates []client.UpdateInfo, since int64) doraStats {
+	var s doraStats
+	var failedAt, previewAt int64
+
+	for _, u := range updates {
+		switch u.Kind {
+		case "preview":
+			if previewAt == 0 && u.StartTime > 0 {
+				previewAt = u.StartTime
+			}
+			continue
+		case "update":
+		default:
+			continue
+		}
+
+		switch u.Result {
+		case "failed":
+			if failedAt == 0 {
+				failedAt = u.EndTime
+			}
+			if u.EndTime >= since {
+				s.failures++
+			}
+		case "succeeded":
+			if u.EndTime >= since {
+				s.deployments++
+				start := u.StartTime
+				if previewAt > 0 && previewAt < start {
+					start = previewAt
+				}
+				if start > 0 && u.EndTime >= start {
+					s.leads++
+					s.leadTotal += float64(u.EndTime - start)
+				}
+				if failedAt > 0 && u.EndTime >= failedAt {
+					s.restores++
+					s.restoreTotal += float64(u.EndTime - failedAt)
+				}
+			}
+			failedAt, previewAt = 0, 0
+		}
+	}
+
+	return s
+}
+
+// addDoraGauges adds the DORA metrics of a stack's update history for every
+// window. Rates and means are only reported when they are defined; the counts
+// and sums behind them are always reported, so they can be aggregated across
+// stacks.
+func (c *Collector) addDoraGauges(gauges *gaugeBatch, updates []client.UpdateInfo, now time.Time, stackAttrs []attribute.KeyValue) {
+	if len(updates) == 0 {
+		return
+	}
+
+	for _, w := range doraWindows {
+		s := computeDora(updates, now.Add(-w.size).Unix())
+		attrs := append(stackAttrs[:len(stackAttrs):len(stackAttrs)], attribute.String("window", w.name))
+
+		gauges.addInt64(c.instruments.doraDeployments, int64(s.deployments), attrs...)
+		gauges.addInt64(c.instruments.doraFailures, int64(s.failures), attrs...)
+		gauges.addInt64(c.instruments.doraRestores, int64(s.restores), attrs...)
+		gauges.addFloat64(c.instruments.doraRestoreTotal, s.restoreTotal, attrs...)
+		gauges.addInt64(c.instruments.doraLeads, int64(s.leads), attrs...)
+		gauges.addFloat64(c.instruments.doraLeadTotal, s.leadTotal, attrs...)
+		if total := s.deployments + s.failures; total > 0 {
+			gauges.addFloat64(c.instruments.doraFailureRate, float64(s.failures)/float64(total), attrs...)
+		}
+		if s.restores > 0 {
+			gauges.addFloat64(c.instruments.doraRestoreTime, s.restoreTotal/float64(s.restores), attrs...)
+		}
+		if s.leads > 0 {
+			gauges.addFloat64(c.instruments.doraLeadTime, s.leadTotal/float64(s.leads), attrs...)
+		}
+	}
+}
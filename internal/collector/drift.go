@@ -0,0 +1,70 @@
+package collector
+
+import (
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/pulumi-labs/pulumi-exporter/internal/client"
+)
+
+// driftState is the drift of a stack according to its latest drift check.
+type driftState struct {
+	checked    int64 // end time of the latest drift check
+	drifted    int   // resources changed by the latest drift check
+	firstSeen  int64 // end time of the first of the consecutive drift checks that found drift
+	hasChecked bool
+}
+
+// driftCheck reports whether update is a successful refresh or preview, the
+// updates that compare a stack with its cloud resources or program without
+// changing them.
+func driftCheck(update client.UpdateInfo) bool {
+	return (update.Kind == "refresh" || update.Kind == "preview") && update.Result == "succeeded"
+}
+
+// driftedResources returns the number of resources an update changed.
+func driftedResources(update client.UpdateInfo) int {
+	var n int
+	for operation, count := range update.ResourceChanges {
+		if operation != "same" {
+			n += count
+		}
+	}
+	return n
+}
+
+// computeDrift returns the drift state of updates, oldest first.
+func computeDrift(updates []client.UpdateInfo) driftState {
+	var s driftState
+	for _, u := range updates {
+		if !driftCheck(u) {
+			continue
+		}
+		s.hasChecked = true
+		s.checked = u.EndTime
+		s.drifted = driftedResources(u)
+		switch {
+		case s.drifted == 0:
+			s.firstSeen = 0
+		case s.firstSeen == 0:
+			s.firstSeen = u.EndTime
+		}
+	}
+	return s
+}
+
+// addDriftGauges adds the drift state of a stack's update history. Stacks
+// without a drift check report nothing.
+func (c *Collector) addDriftGauges(gauges *gaugeBatch, updates []client.UpdateInfo, now time.Time, stackAttrs []attribute.KeyValue) {
+	s := computeDrift(updates)
+	if !s.hasChecked {
+		return
+	}
+
+	gauges.addInt64(c.instruments.stackDriftedResources, int64(s.drifted), stackAttrs...)
+	gauges.addFloat64(c.instruments.stackDriftCheckAge, float64(max(now.Unix()-s.checked, 0)), stackAttrs...)
+	if s.drifted > 0 {
+		gauges.addFloat64(c.instruments.stackDriftAge, float64(max(now.Unix()-s.firstSeen, 0)), stackAttrs...)
+	}
+}
@@ -0,0 +1,53 @@
+package collector
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/pulumi-labs/pulumi-exporter/internal/client"
+	"github.com/pulumi-labs/pulumi-exporter/internal/config"
+)
+
+// updateEnvironmentValue returns the value of the update environment label.
+func updateEnvironmentValue(env client.UpdateEnvironment, label string) string {
+	switch label {
+	case config.UpdateLabelCLIVersion:
+		return env.CLIVersion
+	case config.UpdateLabelExecKind:
+		return env.ExecKind
+	case config.UpdateLabelCISystem:
+		return env.CISystem
+	default:
+		return ""
+	}
+}
+
+// updateEnvironmentAttrs returns the labels selected with --pulumi.update-labels
+// for update.
+func (c *Collector) updateEnvironmentAttrs(update client.UpdateInfo) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, len(c.cfg.Pulumi.UpdateLabels))
+	for _, label := range c.cfg.Pulumi.UpdateLabels {
+		attrs = append(attrs, attribute.String(label, updateEnvironmentValue(update.Environment, label)))
+	}
+	return attrs
+}
+
+// addCLIVersionGauge adds the CLI version, execution kind and CI system of the
+// latest update of a stack that was not a preview. Updates without a CLI
+// version report nothing.
+func (c *Collector) addCLIVersionGauge(gauges *gaugeBatch, updates []client.UpdateInfo, stackAttrs []attribute.KeyValue) {
+	for i := len(updates) - 1; i >= 0; i-- {
+		u := updates[i]
+		if u.Kind == "preview" {
+			continue
+		}
+		if u.Environment.CLIVersion == "" {
+			return
+		}
+		gauges.addInt64(c.instruments.stackCLIVersion, 1, append(stackAttrs[:len(stackAttrs):len(stackAttrs)],
+			attribute.String("version", u.Environment.CLIVersion),
+			attribute.String("exec_kind", u.Environment.ExecKind),
+			attribute.String("ci_system", u.Environment.CISystem),
+		)...)
+		return
+	}
+}
@@ -0,0 +1,109 @@
+package collector
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/pulumi-labs/pulumi-exporter/internal/client"
+	"github.com/pulumi-labs/pulumi-exporter/internal/config"
+)
+
+// Metric families. Each family groups the API calls and metrics of one area
+// of Pulumi Cloud and is collected, scheduled, reported and disabled as a unit.
+const (
+	familyStacks       = config.FamilyStacks
+	familyDeployments  = config.FamilyDeployments
+	familyMembers      = config.FamilyMembers
+	familyTeams        = config.FamilyTeams
+	familyEnvironments = config.FamilyEnvironments
+	familyPolicies     = config.FamilyPolicies
+	familyViolations   = config.FamilyViolations
+	familyNeo          = config.FamilyNeo
+)
+
+// orgFamilyCollector returns the function that collects an org-level family
+// for one organization.
+func (c *Collector) orgFamilyCollector(family string) func(context.Context, string) error {
+	switch family {
+	case familyDeployments:
+		return c.collectOrgDeployments
+	case familyMembers:
+		return c.collectMembers
+	case familyTeams:
+		return c.collectTeams
+	case familyEnvironments:
+		return c.collectEnvironments
+	case familyPolicies:
+		return c.collectPolicies
+	case familyViolations:
+		return c.collectViolations
+	case familyNeo:
+		return c.collectNeo
+	default:
+		panic("unknown org-level metric family: " + family)
+	}
+}
+
+// collectOrgFamily runs fn for one org-level metric family. When the access
+// token is not permitted to read the family's endpoints (401/403), the family
+// is disabled for that org and the condition is logged once instead of every cycle.
+func (c *Collector) collectOrgFamily(ctx context.Context, org, family string, fn func(context.Context, string) error) {
+	if c.familyDenied(org, family) {
+		return
+	}
+
+	start := time.Now()
+	err := fn(ctx, org)
+	if err == nil {
+		c.recordFamilyResult(ctx, org, family, start, nil)
+		return
+	}
+
+	if client.IsPermissionDenied(err) {
+		c.denyFamily(org, family, start, err)
+		return
+	}
+
+	c.recordFamilyResult(ctx, org, family, start, err)
+	c.logger.Error("failed to collect metrics", "org", org, "family", family, "error", err)
+}
+
+// familyDenied reports whether family was disabled for org after a 401/403.
+func (c *Collector) familyDenied(org, family string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, denied := c.deniedFamilies[org+"/"+family]
+	return denied
+}
+
+// denyFamily disables family for org after the API refused access with err.
+func (c *Collector) denyFamily(org, family string, start time.Time, err error) {
+	c.mu.Lock()
+	c.deniedFamilies[org+"/"+family] = struct{}{}
+	c.mu.Unlock()
+	c.status.recordFamily(org, family, start, time.Now(), err, true)
+	c.logger.Warn("access token lacks permission, disabling metric family",
+		"org", org, "family", family, "status", client.StatusCode(err), "error", err)
+}
+
+// recordFamilyResult records the outcome of collecting family for org in the
+// status tracker and, on success, the last successful collection timestamp.
+func (c *Collector) recordFamilyResult(ctx context.Context, org, family string, start time.Time, err error) {
+	end := time.Now()
+	c.status.recordFamily(org, family, start, end, err, false)
+	if err != nil {
+		return
+	}
+
+	c.instruments.lastSuccessCollection.Record(ctx, float64(end.Unix()), metric.WithAttributes(
+		attribute.String("org", org),
+		attribute.String("family", family),
+	))
+}
+
+func orgAttr(org string) attribute.KeyValue {
+	return attribute.String("org", org)
+}
@@ -0,0 +1,109 @@
+package collector
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pulumi-labs/pulumi-exporter/internal/client"
+	"github.com/pulumi-labs/pulumi-exporter/internal/config"
+)
+
+// regexPrefix marks a stack filter pattern as a regular expression.
+const regexPrefix = "re:"
+
+// stackFilter decides which stacks returned by ListStacks are collected. It
+// is applied before any per-stack API call.
+type stackFilter struct {
+	include []stackPattern
+	exclude []stackPattern
+	maxAge  time.Duration
+}
+
+// stackPattern reports whether a stack's "org/project/stack" name matches.
+type stackPattern func(name string) bool
+
+func newStackFilter(cfg config.StackFilterConfig) (*stackFilter, error) {
+	include, err := compileStackPatterns(cfg.Include)
+	if err != nil {
+		return nil, err
+	}
+
+	exclude, err := compileStackPatterns(cfg.Exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stackFilter{include: include, exclude: exclude, maxAge: cfg.MaxAge}, nil
+}
+
+// compileStackPatterns compiles every pattern of patterns.
+func compileStackPatterns(patterns []string) ([]stackPattern, error) {
+	var compiled []stackPattern
+	for _, p := range patterns {
+		m, err := compileStackPattern(p)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, m)
+	}
+	return compiled, nil
+}
+
+// compileStackPattern compiles a stack filter pattern. Globs have the form
+// "project/stack" (any org) or "org/project/stack"; a pattern starting with
+// "re:" is a regular expression matched against the full "org/project/stack".
+func compileStackPattern(p string) (stackPattern, error) {
+	if expr, ok := strings.CutPrefix(p, regexPrefix); ok {
+		re, err := regexp.Compile("^(?:" + expr + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid stack filter %q: %w", p, err)
+		}
+		return re.MatchString, nil
+	}
+
+	switch strings.Count(p, "/") {
+	case 1:
+		p = "*/" + p
+	case 2:
+		// already includes the org
+	default:
+		return nil, fmt.Errorf("invalid stack filter %q: must be project/stack or org/project/stack", p)
+	}
+	if _, err := path.Match(p, ""); err != nil {
+		return nil, fmt.Errorf("invalid stack filter %q: %w", p, err)
+	}
+
+	return func(name string) bool {
+		ok, _ := path.Match(p, name)
+		return ok
+	}, nil
+}
+
+// match reports whether stack passes the include, exclude and max age rules.
+// With a max age set, stacks that were never updated are excluded.
+func (f *stackFilter) match(stack client.StackSummary, now time.Time) bool {
+	name := stack.OrgName + "/" + stack.ProjectName + "/" + stack.StackName
+
+	if len(f.include) > 0 && !matchAny(f.include, name) {
+		return false
+	}
+	if matchAny(f.exclude, name) {
+		return false
+	}
+	if f.maxAge > 0 && now.Sub(time.Unix(stack.LastUpdate, 0)) > f.maxAge {
+		return false
+	}
+	return true
+}
+
+func matchAny(patterns []stackPattern, name string) bool {
+	for _, p := range patterns {
+		if p(name) {
+			return true
+		}
+	}
+	return false
+}
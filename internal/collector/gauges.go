@@ -0,0 +1,105 @@
+package collector
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// gaugeStore holds the gauge values of the latest successful collection of
+// every scope, such as one stack or one org-level endpoint. The observable
+// gauges report exactly what is in the store, so a series disappears as soon
+// as its scope is collected without it or the scope itself is pruned.
+type gaugeStore struct {
+	mu     sync.RWMutex
+	scopes map[string]*gaugeBatch
+}
+
+func newGaugeStore() *gaugeStore {
+	return &gaugeStore{scopes: make(map[string]*gaugeBatch)}
+}
+
+type int64Point struct {
+	gauge metric.Int64ObservableGauge
+	value int64
+	attrs attribute.Set
+}
+
+type float64Point struct {
+	gauge metric.Float64ObservableGauge
+	value float64
+	attrs attribute.Set
+}
+
+// gaugeBatch collects the gauge values of one scope during a collection cycle.
+type gaugeBatch struct {
+	int64s   []int64Point
+	float64s []float64Point
+}
+
+func (b *gaugeBatch) addInt64(gauge metric.Int64ObservableGauge, value int64, attrs ...attribute.KeyValue) {
+	b.int64s = append(b.int64s, int64Point{gauge: gauge, value: value, attrs: attribute.NewSet(attrs...)})
+}
+
+func (b *gaugeBatch) addFloat64(gauge metric.Float64ObservableGauge, value float64, attrs ...attribute.KeyValue) {
+	b.float64s = append(b.float64s, float64Point{gauge: gauge, value: value, attrs: attribute.NewSet(attrs...)})
+}
+
+// replace swaps the values of scope for those in b. Scopes are only replaced
+// after a successful collection, so a failed API call keeps the previous values.
+func (s *gaugeStore) replace(scope string, b *gaugeBatch) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scopes[scope] = b
+}
+
+// prune drops every scope starting with prefix that is not in keep.
+func (s *gaugeStore) prune(prefix string, keep map[string]struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for scope := range s.scopes {
+		if _, ok := keep[scope]; !ok && strings.HasPrefix(scope, prefix) {
+			delete(s.scopes, scope)
+		}
+	}
+}
+
+// observe reports every stored value. It is registered as the callback of all
+// observable gauges.
+func (s *gaugeStore) observe(_ context.Context, o metric.Observer) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, b := range s.scopes {
+		for _, p := range b.int64s {
+			o.ObserveInt64(p.gauge, p.value, metric.WithAttributeSet(p.attrs))
+		}
+		for _, p := range b.float64s {
+			o.ObserveFloat64(p.gauge, p.value, metric.WithAttributeSet(p.attrs))
+		}
+	}
+	return nil
+}
+
+// stackScope returns the gauge scope of the stack with the given org/project/stack key.
+func stackScope(stackKey string) string {
+	return "stack/" + stackKey
+}
+
+// orgScope returns the gauge scope of one org-level endpoint.
+func orgScope(org, name string) string {
+	return "org/" + org + "/" + name
+}
+
+// countBuckets returns counts with every known bucket present, so buckets that
+// dropped to zero are reported as 0 instead of keeping their last value.
+func countBuckets(counts map[string]int64, known ...string) map[string]int64 {
+	for _, k := range known {
+		if _, ok := counts[k]; !ok {
+			counts[k] = 0
+		}
+	}
+	return counts
+}
@@ -0,0 +1,119 @@
+package collector
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/pulumi-labs/pulumi-exporter/internal/client"
+)
+
+// historyRetention is how long updates are kept in a stack's history. It
+// covers the longest DORA window.
+const historyRetention = 30 * 24 * time.Hour
+
+// historyMinUpdates is the number of newest updates kept in a stack's history
+// regardless of their age, so stacks that are rarely updated keep their
+// latest results.
+const historyMinUpdates = updatesPageSize
+
+// updateHistory keeps the recent updates of every stack in memory, ordered by
+// version, so that metrics over rolling windows can be derived from more than
+// the updates seen in a single cycle. It is seeded from the newest page of
+// updates fetched after a restart.
+type updateHistory struct {
+	mu     sync.Mutex
+	stacks map[string][]client.UpdateInfo
+}
+
+func newUpdateHistory() *updateHistory {
+	return &updateHistory{stacks: make(map[string][]client.UpdateInfo)}
+}
+
+// merge adds updates to the history of stackKey. An update with a version
+// already in the history replaces it, so in-progress updates pick up their
+// final result. Updates older than historyRetention are dropped unless they
+// are among the newest historyMinUpdates.
+func (h *updateHistory) merge(stackKey string, updates []client.UpdateInfo, now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	byVersion := make(map[int]client.UpdateInfo, len(h.stacks[stackKey])+len(updates))
+	for _, u := range h.stacks[stackKey] {
+		byVersion[u.Version] = u
+	}
+	for _, u := range updates {
+		byVersion[u.Version] = u
+	}
+
+	merged := make([]client.UpdateInfo, 0, len(byVersion))
+	for _, u := range byVersion {
+		merged = append(merged, u)
+	}
+	slices.SortFunc(merged, func(a, b client.UpdateInfo) int { return a.Version - b.Version })
+
+	cutoff := now.Add(-historyRetention).Unix()
+	first := 0
+	for first < len(merged)-historyMinUpdates && merged[first].StartTime < cutoff {
+		first++
+	}
+	h.stacks[stackKey] = merged[first:]
+}
+
+// updates returns a copy of the history of stackKey, oldest first.
+func (h *updateHistory) updates(stackKey string) []client.UpdateInfo {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return slices.Clone(h.stacks[stackKey])
+}
+
+// prune drops the history of every stack not in keep.
+func (h *updateHistory) prune(keep map[string]struct{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for stackKey := range h.stacks {
+		if _, ok := keep[stackKey]; !ok {
+			delete(h.stacks, stackKey)
+		}
+	}
+}
+
+// historyScope returns the gauge scope of the metrics derived from the update
+// history of the stack with the given org/project/stack key.
+func historyScope(stackKey string) string {
+	return "history/" + stackKey
+}
+
+// recordStackHistories derives the gauges of every listed stack from its
+// update history. It runs at the end of every stacks cycle, including for
+// stacks that were not collected in it, so rolling windows keep moving.
+func (c *Collector) recordStackHistories(stacks []client.StackSummary, now time.Time) {
+	keepScopes := make(map[string]struct{}, len(stacks))
+	keepStacks := make(map[string]struct{}, len(stacks))
+
+	for _, stack := range stacks {
+		stackKey := stack.OrgName + "/" + stack.ProjectName + "/" + stack.StackName
+		stackAttrs := []attribute.KeyValue{
+			attribute.String("org", stack.OrgName),
+			attribute.String("project", stack.ProjectName),
+			attribute.String("stack", stack.StackName),
+		}
+
+		var gauges gaugeBatch
+		updates := c.history.updates(stackKey)
+		c.addDoraGauges(&gauges, updates, now, stackAttrs)
+		c.addResultGauges(&gauges, updates, stackAttrs)
+		c.addInProgressGauges(&gauges, updates, now, stackAttrs)
+		c.addDriftGauges(&gauges, updates, now, stackAttrs)
+		c.addCLIVersionGauge(&gauges, updates, stackAttrs)
+
+		c.instruments.gauges.replace(historyScope(stackKey), &gauges)
+		keepScopes[historyScope(stackKey)] = struct{}{}
+		keepStacks[stackKey] = struct{}{}
+	}
+
+	c.instruments.gauges.prune(historyScope(""), keepScopes)
+	c.history.prune(keepStacks)
+}
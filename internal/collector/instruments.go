@@ -5,32 +5,92 @@ import (
 )
 
 // Instruments holds all OTel metric instruments used by the collector.
+// Gauges are observable: they report the values held in gauges, which the
+// collector replaces after every successful collection.
 type Instruments struct {
-	stackResourceCount    metric.Int64Gauge
+	gauges *gaugeStore
+
+	stackResourceCount    metric.Int64ObservableGauge
 	updateDuration        metric.Float64Histogram
 	updateTotal           metric.Int64Counter
 	updateResourceChanges metric.Int64Counter
-	deploymentStatus      metric.Int64Gauge
-	stackLastUpdate       metric.Float64Gauge
-
-	orgMemberCount        metric.Int64Gauge
-	orgTeamCount          metric.Int64Gauge
-	orgEnvironmentCount   metric.Int64Gauge
-	orgPolicyGroupCount   metric.Int64Gauge
-	orgPolicyPackCount    metric.Int64Gauge
-	orgPolicyViolations   metric.Int64Gauge
-	orgNeoTaskCount       metric.Int64Gauge
-	orgNeoTokensUsedMonth metric.Int64Gauge
-	orgNeoTokensUsedTotal metric.Int64Gauge
-
-	orgNeoTokenBudgetConsumed  metric.Int64Gauge
-	orgNeoTokenBudgetAllowance metric.Int64Gauge
-	orgNeoTokenBudgetExhausted metric.Int64Gauge
-
-	orgPolicyTotal      metric.Int64Gauge
-	orgPolicyWithIssues metric.Int64Gauge
-	orgResourcesTotal   metric.Int64Gauge
-	orgResourcesIssues  metric.Int64Gauge
+	updateSkipped         metric.Int64Counter
+	deploymentStatus      metric.Int64ObservableGauge
+	deploymentTotal       metric.Int64Counter
+	deploymentDuration    metric.Float64Histogram
+	deploymentQueueTime   metric.Float64Histogram
+	deploymentStepTime    metric.Float64Histogram
+	deploymentStepFailed  metric.Int64Counter
+	stackLastUpdate       metric.Float64ObservableGauge
+	stackDeploymentStatus metric.Int64ObservableGauge
+	stackDeploymentTime   metric.Float64ObservableGauge
+	stackDeploymentPaused metric.Int64ObservableGauge
+
+	stackLastResult    metric.Int64ObservableGauge
+	stackFailureStreak metric.Int64ObservableGauge
+	stackLastAttempted metric.Float64ObservableGauge
+	stackLastSucceeded metric.Float64ObservableGauge
+	stackInProgressAge metric.Float64ObservableGauge
+	stackCLIVersion    metric.Int64ObservableGauge
+
+	stackDriftedResources metric.Int64ObservableGauge
+	stackDriftCheckAge    metric.Float64ObservableGauge
+	stackDriftAge         metric.Float64ObservableGauge
+
+	doraDeployments metric.Int64ObservableGauge
+	doraFailureRate metric.Float64ObservableGauge
+	doraRestoreTime metric.Float64ObservableGauge
+	doraLeadTime    metric.Float64ObservableGauge
+
+	doraFailures     metric.Int64ObservableGauge
+	doraRestores     metric.Int64ObservableGauge
+	doraRestoreTotal metric.Float64ObservableGauge
+	doraLeads        metric.Int64ObservableGauge
+	doraLeadTotal    metric.Float64ObservableGauge
+
+	orgMemberCount        metric.Int64ObservableGauge
+	orgTeamCount          metric.Int64ObservableGauge
+	orgEnvironmentCount   metric.Int64ObservableGauge
+	orgPolicyGroupCount   metric.Int64ObservableGauge
+	orgPolicyPackCount    metric.Int64ObservableGauge
+	orgPolicyViolations   metric.Int64ObservableGauge
+	orgNeoTaskCount       metric.Int64ObservableGauge
+	orgNeoTokensUsedMonth metric.Int64ObservableGauge
+	orgNeoTokensUsedTotal metric.Int64ObservableGauge
+
+	orgNeoTokenBudgetConsumed  metric.Int64ObservableGauge
+	orgNeoTokenBudgetAllowance metric.Int64ObservableGauge
+	orgNeoTokenBudgetExhausted metric.Int64ObservableGauge
+
+	orgPolicyTotal      metric.Int64ObservableGauge
+	orgPolicyWithIssues metric.Int64ObservableGauge
+	orgResourcesTotal   metric.Int64ObservableGauge
+	orgResourcesIssues  metric.Int64ObservableGauge
+
+	policyGroupStacks       metric.Int64ObservableGauge
+	policyGroupPacks        metric.Int64ObservableGauge
+	orgUngovernedStacks     metric.Int64ObservableGauge
+	projectUngovernedStacks metric.Int64ObservableGauge
+
+	requiredPackMissingStacks  metric.Int64ObservableGauge
+	stackRequiredPackMissing   metric.Int64ObservableGauge
+	requiredPackVersionsBehind metric.Int64ObservableGauge
+
+	orgViolationsByPolicy  metric.Int64ObservableGauge
+	orgViolationsByStack   metric.Int64ObservableGauge
+	orgViolationsByType    metric.Int64ObservableGauge
+	orgViolationsTruncated metric.Int64ObservableGauge
+
+	violationsOpened     metric.Int64Counter
+	violationsResolved   metric.Int64Counter
+	violationRemediation metric.Float64Histogram
+	violationOldestAge   metric.Float64ObservableGauge
+
+	collectDuration       metric.Float64Histogram
+	collectTimeouts       metric.Int64Counter
+	collectStacks         metric.Int64Counter
+	pollTierStacks        metric.Int64ObservableGauge
+	lastSuccessCollection metric.Float64Gauge
 }
 
 // NewInstruments creates all OTel metric instruments.
@@ -38,7 +98,7 @@ func NewInstruments(meter metric.Meter) (*Instruments, error) {
 	var ins Instruments
 	var err error
 
-	if ins.stackResourceCount, err = meter.Int64Gauge("pulumi_stack_resource_count",
+	if ins.stackResourceCount, err = meter.Int64ObservableGauge("pulumi_stack_resource_count",
 		metric.WithDescription("Number of resources in a Pulumi stack"),
 	); err != nil {
 		return nil, err
@@ -63,83 +123,440 @@ func NewInstruments(meter metric.Meter) (*Instruments, error) {
 		return nil, err
 	}
 
-	if ins.deploymentStatus, err = meter.Int64Gauge("pulumi_deployment_status",
+	if ins.updateSkipped, err = meter.Int64Counter("pulumi_update_skipped_total",
+		metric.WithDescription("Number of Pulumi stack updates not counted because the update page cap was reached"),
+	); err != nil {
+		return nil, err
+	}
+
+	if ins.deploymentStatus, err = meter.Int64ObservableGauge("pulumi_deployment_status",
 		metric.WithDescription("Number of Pulumi deployments by status"),
 	); err != nil {
 		return nil, err
 	}
 
-	if ins.stackLastUpdate, err = meter.Float64Gauge("pulumi_stack_last_update_timestamp",
+	if ins.deploymentTotal, err = meter.Int64Counter("pulumi_deployment_total",
+		metric.WithDescription("Total number of finished Pulumi deployments"),
+	); err != nil {
+		return nil, err
+	}
+
+	if ins.deploymentDuration, err = meter.Float64Histogram("pulumi_deployment_duration_seconds",
+		metric.WithDescription("Duration of finished Pulumi deployments from the first job start to the last job update in seconds"),
+		metric.WithExplicitBucketBoundaries(10, 30, 60, 120, 300, 600, 1800, 3600),
+	); err != nil {
+		return nil, err
+	}
+
+	if ins.deploymentQueueTime, err = meter.Float64Histogram("pulumi_deployment_queue_seconds",
+		metric.WithDescription("Time Pulumi deployments waited from creation to the first job start in seconds"),
+		metric.WithExplicitBucketBoundaries(1, 5, 10, 30, 60, 120, 300, 600),
+	); err != nil {
+		return nil, err
+	}
+
+	if ins.deploymentStepTime, err = meter.Float64Histogram("pulumi_deployment_step_duration_seconds",
+		metric.WithDescription("Duration of the steps of finished Pulumi deployment jobs in seconds"),
+		metric.WithExplicitBucketBoundaries(1, 5, 10, 30, 60, 120, 300, 600, 1800),
+	); err != nil {
+		return nil, err
+	}
+
+	if ins.deploymentStepFailed, err = meter.Int64Counter("pulumi_deployment_step_failures_total",
+		metric.WithDescription("Total number of failed Pulumi deployment job steps"),
+	); err != nil {
+		return nil, err
+	}
+
+	if ins.stackLastUpdate, err = meter.Float64ObservableGauge("pulumi_stack_last_update_timestamp",
 		metric.WithDescription("Unix timestamp of the last update to a Pulumi stack"),
 	); err != nil {
 		return nil, err
 	}
 
+	if ins.stackDeploymentStatus, err = meter.Int64ObservableGauge("pulumi_stack_last_deployment_status",
+		metric.WithDescription("Status of the latest Pulumi deployment of a stack (1 for the current status)"),
+	); err != nil {
+		return nil, err
+	}
+
+	if ins.stackDeploymentTime, err = meter.Float64ObservableGauge("pulumi_stack_last_deployment_timestamp",
+		metric.WithDescription("Unix timestamp of the creation of the latest Pulumi deployment of a stack"),
+	); err != nil {
+		return nil, err
+	}
+
+	if ins.stackDeploymentPaused, err = meter.Int64ObservableGauge("pulumi_stack_deployments_paused",
+		metric.WithDescription("Whether Pulumi deployments are paused for a stack (1) or not (0)"),
+	); err != nil {
+		return nil, err
+	}
+
+	if err = newResultInstruments(meter, &ins); err != nil {
+		return nil, err
+	}
+
+	if err = newDriftInstruments(meter, &ins); err != nil {
+		return nil, err
+	}
+
+	if err = newDoraInstruments(meter, &ins); err != nil {
+		return nil, err
+	}
+
 	if err = newOrgInstruments(meter, &ins); err != nil {
 		return nil, err
 	}
 
+	if err = newSelfInstruments(meter, &ins); err != nil {
+		return nil, err
+	}
+
+	ins.gauges = newGaugeStore()
+	if _, err = meter.RegisterCallback(ins.gauges.observe, ins.observableGauges()...); err != nil {
+		return nil, err
+	}
+
 	return &ins, nil
 }
 
+// observableGauges returns every gauge reported from the gauge store.
+func (ins *Instruments) observableGauges() []metric.Observable {
+	return []metric.Observable{
+		ins.stackResourceCount,
+		ins.deploymentStatus,
+		ins.stackLastUpdate,
+		ins.stackDeploymentStatus,
+		ins.stackDeploymentTime,
+		ins.stackDeploymentPaused,
+		ins.stackLastResult,
+		ins.stackFailureStreak,
+		ins.stackLastAttempted,
+		ins.stackLastSucceeded,
+		ins.stackInProgressAge,
+		ins.stackCLIVersion,
+		ins.stackDriftedResources,
+		ins.stackDriftCheckAge,
+		ins.stackDriftAge,
+		ins.doraDeployments,
+		ins.doraFailureRate,
+		ins.doraRestoreTime,
+		ins.doraLeadTime,
+		ins.doraFailures,
+		ins.doraRestores,
+		ins.doraRestoreTotal,
+		ins.doraLeads,
+		ins.doraLeadTotal,
+		ins.orgMemberCount,
+		ins.orgTeamCount,
+		ins.orgEnvironmentCount,
+		ins.orgPolicyGroupCount,
+		ins.orgPolicyPackCount,
+		ins.orgPolicyViolations,
+		ins.policyGroupStacks,
+		ins.policyGroupPacks,
+		ins.orgUngovernedStacks,
+		ins.projectUngovernedStacks,
+		ins.requiredPackMissingStacks,
+		ins.stackRequiredPackMissing,
+		ins.requiredPackVersionsBehind,
+		ins.orgViolationsByPolicy,
+		ins.orgViolationsByStack,
+		ins.orgViolationsByType,
+		ins.orgViolationsTruncated,
+		ins.violationOldestAge,
+		ins.orgNeoTaskCount,
+		ins.orgNeoTokensUsedMonth,
+		ins.orgNeoTokensUsedTotal,
+		ins.orgNeoTokenBudgetConsumed,
+		ins.orgNeoTokenBudgetAllowance,
+		ins.orgNeoTokenBudgetExhausted,
+		ins.orgPolicyTotal,
+		ins.orgPolicyWithIssues,
+		ins.orgResourcesTotal,
+		ins.orgResourcesIssues,
+		ins.pollTierStacks,
+	}
+}
+
+// newResultInstruments registers the gauges of the latest update results of stacks.
+func newResultInstruments(meter metric.Meter, ins *Instruments) error {
+	var err error
+
+	if ins.stackLastResult, err = meter.Int64ObservableGauge("pulumi_stack_last_update_result",
+		metric.WithDescription("1 for the result of the latest Pulumi update of a stack by kind"),
+	); err != nil {
+		return err
+	}
+
+	if ins.stackFailureStreak, err = meter.Int64ObservableGauge("pulumi_stack_consecutive_failures",
+		metric.WithDescription("Number of failed Pulumi updates of a stack since its last successful one by kind"),
+	); err != nil {
+		return err
+	}
+
+	if ins.stackLastAttempted, err = meter.Float64ObservableGauge("pulumi_stack_last_attempted_update_timestamp",
+		metric.WithDescription("Unix timestamp of the start of the latest Pulumi update of a stack by kind"),
+	); err != nil {
+		return err
+	}
+
+	if ins.stackLastSucceeded, err = meter.Float64ObservableGauge("pulumi_stack_last_successful_update_timestamp",
+		metric.WithDescription("Unix timestamp of the end of the latest successful Pulumi update of a stack by kind"),
+	); err != nil {
+		return err
+	}
+
+	if ins.stackInProgressAge, err = meter.Float64ObservableGauge("pulumi_stack_update_in_progress_seconds",
+		metric.WithDescription("Age of the oldest unfinished Pulumi update of a stack by kind in seconds"),
+	); err != nil {
+		return err
+	}
+
+	if ins.stackCLIVersion, err = meter.Int64ObservableGauge("pulumi_stack_cli_version_info",
+		metric.WithDescription("1 for the Pulumi CLI version, execution kind and CI system of the latest non-preview update of a stack"),
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// newDriftInstruments registers the drift gauges derived from refresh and preview updates.
+func newDriftInstruments(meter metric.Meter, ins *Instruments) error {
+	var err error
+
+	if ins.stackDriftedResources, err = meter.Int64ObservableGauge("pulumi_stack_drifted_resources",
+		metric.WithDescription("Number of resources changed by the latest Pulumi refresh or preview of a stack"),
+	); err != nil {
+		return err
+	}
+
+	if ins.stackDriftCheckAge, err = meter.Float64ObservableGauge("pulumi_stack_drift_check_age_seconds",
+		metric.WithDescription("Time since the latest Pulumi refresh or preview of a stack in seconds"),
+	); err != nil {
+		return err
+	}
+
+	if ins.stackDriftAge, err = meter.Float64ObservableGauge("pulumi_stack_drift_age_seconds",
+		metric.WithDescription("Time since drift of a stack was first seen by a Pulumi refresh or preview in seconds"),
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// newDoraInstruments registers the DORA gauges derived from stack update histories.
+func newDoraInstruments(meter metric.Meter, ins *Instruments) error {
+	var err error
+
+	if ins.doraDeployments, err = meter.Int64ObservableGauge("pulumi_dora_deployments",
+		metric.WithDescription("Number of successful Pulumi updates of a stack in the window"),
+	); err != nil {
+		return err
+	}
+
+	if ins.doraFailureRate, err = meter.Float64ObservableGauge("pulumi_dora_change_failure_rate",
+		metric.WithDescription("Ratio of failed to finished Pulumi updates of a stack in the window"),
+	); err != nil {
+		return err
+	}
+
+	if ins.doraRestoreTime, err = meter.Float64ObservableGauge("pulumi_dora_time_to_restore_seconds",
+		metric.WithDescription("Mean time from the first failed Pulumi update of a stack to the next successful one in the window in seconds"),
+	); err != nil {
+		return err
+	}
+
+	if ins.doraLeadTime, err = meter.Float64ObservableGauge("pulumi_dora_lead_time_seconds",
+		metric.WithDescription("Mean time from the first preview of a change to the end of its successful Pulumi update in the window in seconds"),
+	); err != nil {
+		return err
+	}
+
+	if ins.doraFailures, err = meter.Int64ObservableGauge("pulumi_dora_failed_deployments",
+		metric.WithDescription("Number of failed Pulumi updates of a stack in the window"),
+	); err != nil {
+		return err
+	}
+
+	if ins.doraRestores, err = meter.Int64ObservableGauge("pulumi_dora_restores",
+		metric.WithDescription("Number of successful Pulumi updates of a stack in the window that ended a failure streak"),
+	); err != nil {
+		return err
+	}
+
+	if ins.doraRestoreTotal, err = meter.Float64ObservableGauge("pulumi_dora_restore_seconds_window_total",
+		metric.WithDescription("Sum of the times from the first failed Pulumi update of a stack to the next successful one in the window in seconds"),
+	); err != nil {
+		return err
+	}
+
+	if ins.doraLeads, err = meter.Int64ObservableGauge("pulumi_dora_lead_time_deployments",
+		metric.WithDescription("Number of successful Pulumi updates of a stack in the window with a lead time"),
+	); err != nil {
+		return err
+	}
+
+	if ins.doraLeadTotal, err = meter.Float64ObservableGauge("pulumi_dora_lead_time_seconds_window_total",
+		metric.WithDescription("Sum of the lead times of the successful Pulumi updates of a stack in the window in seconds"),
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func newOrgInstruments(meter metric.Meter, ins *Instruments) error {
 	var err error
 
-	if ins.orgMemberCount, err = meter.Int64Gauge("pulumi_org_member_count",
+	if ins.orgMemberCount, err = meter.Int64ObservableGauge("pulumi_org_member_count",
 		metric.WithDescription("Number of members in a Pulumi organization"),
 	); err != nil {
 		return err
 	}
 
-	if ins.orgTeamCount, err = meter.Int64Gauge("pulumi_org_team_count",
+	if ins.orgTeamCount, err = meter.Int64ObservableGauge("pulumi_org_team_count",
 		metric.WithDescription("Number of teams in a Pulumi organization"),
 	); err != nil {
 		return err
 	}
 
-	if ins.orgEnvironmentCount, err = meter.Int64Gauge("pulumi_org_environment_count",
+	if ins.orgEnvironmentCount, err = meter.Int64ObservableGauge("pulumi_org_environment_count",
 		metric.WithDescription("Number of ESC environments in a Pulumi organization"),
 	); err != nil {
 		return err
 	}
 
-	if ins.orgPolicyGroupCount, err = meter.Int64Gauge("pulumi_org_policy_group_count",
+	if ins.orgPolicyGroupCount, err = meter.Int64ObservableGauge("pulumi_org_policy_group_count",
 		metric.WithDescription("Number of policy groups in a Pulumi organization"),
 	); err != nil {
 		return err
 	}
 
-	if ins.orgPolicyPackCount, err = meter.Int64Gauge("pulumi_org_policy_pack_count",
+	if ins.orgPolicyPackCount, err = meter.Int64ObservableGauge("pulumi_org_policy_pack_count",
 		metric.WithDescription("Number of policy packs in a Pulumi organization"),
 	); err != nil {
 		return err
 	}
 
-	if ins.orgPolicyViolations, err = meter.Int64Gauge("pulumi_org_policy_violations",
+	if ins.policyGroupStacks, err = meter.Int64ObservableGauge("pulumi_org_policy_group_stacks",
+		metric.WithDescription("Number of stacks in a policy group"),
+	); err != nil {
+		return err
+	}
+
+	if ins.policyGroupPacks, err = meter.Int64ObservableGauge("pulumi_org_policy_group_enabled_policy_packs",
+		metric.WithDescription("Number of policy packs enabled in a policy group"),
+	); err != nil {
+		return err
+	}
+
+	if ins.orgUngovernedStacks, err = meter.Int64ObservableGauge("pulumi_org_policy_ungoverned_stacks",
+		metric.WithDescription("Number of stacks not in any policy group other than the default"),
+	); err != nil {
+		return err
+	}
+
+	if ins.projectUngovernedStacks, err = meter.Int64ObservableGauge("pulumi_org_policy_ungoverned_project_stacks",
+		metric.WithDescription("Number of stacks of a project not in any policy group other than the default"),
+	); err != nil {
+		return err
+	}
+
+	if ins.requiredPackMissingStacks, err = meter.Int64ObservableGauge("pulumi_org_policy_required_pack_missing_stacks",
+		metric.WithDescription("Number of stacks that require a policy pack not enabled in any of their policy groups"),
+	); err != nil {
+		return err
+	}
+
+	if ins.stackRequiredPackMissing, err = meter.Int64ObservableGauge("pulumi_stack_required_policy_pack_missing",
+		metric.WithDescription("1 for a required policy pack not enabled in any policy group of a stack"),
+	); err != nil {
+		return err
+	}
+
+	if ins.requiredPackVersionsBehind, err = meter.Int64ObservableGauge("pulumi_org_policy_required_pack_versions_behind",
+		metric.WithDescription("Number of published versions of a required policy pack newer than the version enabled in a policy group"),
+	); err != nil {
+		return err
+	}
+
+	if ins.orgPolicyViolations, err = meter.Int64ObservableGauge("pulumi_org_policy_violations",
 		metric.WithDescription("Number of policy violations by level and kind"),
 	); err != nil {
 		return err
 	}
 
-	if ins.orgPolicyTotal, err = meter.Int64Gauge("pulumi_org_policy_total",
+	if ins.orgViolationsByPolicy, err = meter.Int64ObservableGauge("pulumi_org_policy_violations_by_policy",
+		metric.WithDescription("Number of policy violations of the policies with the most violations"),
+	); err != nil {
+		return err
+	}
+
+	if ins.orgViolationsByStack, err = meter.Int64ObservableGauge("pulumi_org_policy_violations_by_stack",
+		metric.WithDescription("Number of policy violations of the stacks with the most violations"),
+	); err != nil {
+		return err
+	}
+
+	if ins.orgViolationsByType, err = meter.Int64ObservableGauge("pulumi_org_policy_violations_by_resource_type",
+		metric.WithDescription("Number of policy violations of the resource types with the most violations"),
+	); err != nil {
+		return err
+	}
+
+	if ins.orgViolationsTruncated, err = meter.Int64ObservableGauge("pulumi_org_policy_violations_truncated",
+		metric.WithDescription("Whether the policy violations of a Pulumi organization were cut off by the page cap (1) or not (0)"),
+	); err != nil {
+		return err
+	}
+
+	if ins.violationsOpened, err = meter.Int64Counter("pulumi_org_policy_violations_opened_total",
+		metric.WithDescription("Total number of newly observed policy violations"),
+	); err != nil {
+		return err
+	}
+
+	if ins.violationsResolved, err = meter.Int64Counter("pulumi_org_policy_violations_resolved_total",
+		metric.WithDescription("Total number of resolved policy violations"),
+	); err != nil {
+		return err
+	}
+
+	if ins.violationRemediation, err = meter.Float64Histogram("pulumi_org_policy_violation_remediation_seconds",
+		metric.WithDescription("Time from the first observation of a policy violation to its resolution in seconds"),
+		metric.WithExplicitBucketBoundaries(3600, 6*3600, 86400, 3*86400, 7*86400, 14*86400, 30*86400, 90*86400),
+	); err != nil {
+		return err
+	}
+
+	if ins.violationOldestAge, err = meter.Float64ObservableGauge("pulumi_org_policy_violation_oldest_age_seconds",
+		metric.WithDescription("Age of the oldest open policy violation by policy pack and level in seconds"),
+	); err != nil {
+		return err
+	}
+
+	if ins.orgPolicyTotal, err = meter.Int64ObservableGauge("pulumi_org_policy_total",
 		metric.WithDescription("Total number of policies in a Pulumi organization"),
 	); err != nil {
 		return err
 	}
 
-	if ins.orgPolicyWithIssues, err = meter.Int64Gauge("pulumi_org_policy_with_issues",
+	if ins.orgPolicyWithIssues, err = meter.Int64ObservableGauge("pulumi_org_policy_with_issues",
 		metric.WithDescription("Number of policies with issues in a Pulumi organization"),
 	); err != nil {
 		return err
 	}
 
-	if ins.orgResourcesTotal, err = meter.Int64Gauge("pulumi_org_governed_resources_total",
+	if ins.orgResourcesTotal, err = meter.Int64ObservableGauge("pulumi_org_governed_resources_total",
 		metric.WithDescription("Total number of resources governed by policies in a Pulumi organization"),
 	); err != nil {
 		return err
 	}
 
-	if ins.orgResourcesIssues, err = meter.Int64Gauge("pulumi_org_governed_resources_with_issues",
+	if ins.orgResourcesIssues, err = meter.Int64ObservableGauge("pulumi_org_governed_resources_with_issues",
 		metric.WithDescription("Number of governed resources with issues in a Pulumi organization"),
 	); err != nil {
 		return err
@@ -157,37 +574,37 @@ func newOrgInstruments(meter metric.Meter, ins *Instruments) error {
 func newOrgNeoInstruments(meter metric.Meter, ins *Instruments) error {
 	var err error
 
-	if ins.orgNeoTaskCount, err = meter.Int64Gauge("pulumi_org_neo_task_count",
+	if ins.orgNeoTaskCount, err = meter.Int64ObservableGauge("pulumi_org_neo_task_count",
 		metric.WithDescription("Number of Pulumi Neo AI tasks by status"),
 	); err != nil {
 		return err
 	}
 
-	if ins.orgNeoTokensUsedMonth, err = meter.Int64Gauge("pulumi_org_neo_tokens_used_current_month",
+	if ins.orgNeoTokensUsedMonth, err = meter.Int64ObservableGauge("pulumi_org_neo_tokens_used_current_month",
 		metric.WithDescription("Neo tokens consumed by AI tasks created in the current calendar month (matches the Pulumi Cloud billing-period usage)"),
 	); err != nil {
 		return err
 	}
 
-	if ins.orgNeoTokensUsedTotal, err = meter.Int64Gauge("pulumi_org_neo_tokens_used_total",
+	if ins.orgNeoTokensUsedTotal, err = meter.Int64ObservableGauge("pulumi_org_neo_tokens_used_total",
 		metric.WithDescription("Total Neo tokens consumed across all Pulumi Neo AI tasks (lifetime)"),
 	); err != nil {
 		return err
 	}
 
-	if ins.orgNeoTokenBudgetConsumed, err = meter.Int64Gauge("pulumi_org_neo_token_budget_consumed",
+	if ins.orgNeoTokenBudgetConsumed, err = meter.Int64ObservableGauge("pulumi_org_neo_token_budget_consumed",
 		metric.WithDescription("Neo tokens consumed in the current budget window for a Pulumi organization"),
 	); err != nil {
 		return err
 	}
 
-	if ins.orgNeoTokenBudgetAllowance, err = meter.Int64Gauge("pulumi_org_neo_token_budget_allowance",
+	if ins.orgNeoTokenBudgetAllowance, err = meter.Int64ObservableGauge("pulumi_org_neo_token_budget_allowance",
 		metric.WithDescription("Effective Neo token allowance for the current budget window (base plus any active bonus)"),
 	); err != nil {
 		return err
 	}
 
-	if ins.orgNeoTokenBudgetExhausted, err = meter.Int64Gauge("pulumi_org_neo_token_budget_exhausted",
+	if ins.orgNeoTokenBudgetExhausted, err = meter.Int64ObservableGauge("pulumi_org_neo_token_budget_exhausted",
 		metric.WithDescription("Whether the Neo token budget for the current window is exhausted (1) or not (0)"),
 	); err != nil {
 		return err
@@ -195,3 +612,42 @@ func newOrgNeoInstruments(meter metric.Meter, ins *Instruments) error {
 
 	return nil
 }
+
+// newSelfInstruments registers the instruments describing the health of the
+// exporter's own collection loop.
+func newSelfInstruments(meter metric.Meter, ins *Instruments) error {
+	var err error
+
+	if ins.collectDuration, err = meter.Float64Histogram("pulumi_exporter_collect_duration_seconds",
+		metric.WithDescription("Duration of a collection cycle of one metric family in seconds"),
+		metric.WithExplicitBucketBoundaries(1, 5, 10, 30, 60, 120, 300, 600),
+	); err != nil {
+		return err
+	}
+
+	if ins.collectTimeouts, err = meter.Int64Counter("pulumi_exporter_collect_timeouts_total",
+		metric.WithDescription("Number of collection cycles cancelled by the collection timeout"),
+	); err != nil {
+		return err
+	}
+
+	if ins.collectStacks, err = meter.Int64Counter("pulumi_exporter_stacks_total",
+		metric.WithDescription("Number of stacks handled per collection cycle by outcome (processed, skipped, failed, filtered, unchanged, deferred)"),
+	); err != nil {
+		return err
+	}
+
+	if ins.pollTierStacks, err = meter.Int64ObservableGauge("pulumi_exporter_poll_tier_stacks",
+		metric.WithDescription("Number of stacks in each adaptive polling tier"),
+	); err != nil {
+		return err
+	}
+
+	if ins.lastSuccessCollection, err = meter.Float64Gauge("pulumi_exporter_last_successful_collection_timestamp",
+		metric.WithDescription("Unix timestamp of the last successful collection of a metric family for an organization"),
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -2,120 +2,181 @@ package collector
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
-	"golang.org/x/sync/errgroup"
+
+	"github.com/pulumi-labs/pulumi-exporter/internal/client"
 )
 
-func (c *Collector) collectOrgMetrics(ctx context.Context, org string) {
-	orgAttr := metric.WithAttributes(attribute.String("org", org))
-
-	g, gCtx := errgroup.WithContext(ctx)
-	g.Go(func() error { c.collectMembers(gCtx, org, orgAttr); return nil })
-	g.Go(func() error { c.collectTeams(gCtx, org, orgAttr); return nil })
-	g.Go(func() error { c.collectEnvironments(gCtx, org, orgAttr); return nil })
-	g.Go(func() error { c.collectPolicyGroups(gCtx, org, orgAttr); return nil })
-	g.Go(func() error { c.collectPolicyPacks(gCtx, org, orgAttr); return nil })
-	g.Go(func() error { c.collectPolicyViolations(gCtx, org); return nil })
-	g.Go(func() error { c.collectNeoTasks(gCtx, org); return nil })
-	g.Go(func() error { c.collectNeoTokenBudget(gCtx, org, orgAttr); return nil })
-	g.Go(func() error { c.collectPolicyResultsMetadata(gCtx, org, orgAttr); return nil })
-	_ = g.Wait()
-}
+// Label values always reported by the org-level count gauges, as 0 when an org
+// has nothing in that bucket.
+var (
+	neoTaskStatuses = []string{"idle", "running"}
+	policyLevels    = []string{"advisory", "mandatory", "remediate"}
+	policyKinds     = []string{"audit", "preventative"}
+)
 
-func (c *Collector) collectMembers(ctx context.Context, org string, attrs metric.MeasurementOption) {
+func (c *Collector) collectMembers(ctx context.Context, org string) error {
 	resp, err := c.client.ListMembers(ctx, org)
 	if err != nil {
-		c.logger.Error("failed to list members", "org", org, "error", err)
-		return
+		return err
 	}
-	c.instruments.orgMemberCount.Record(ctx, int64(len(resp.Members)), attrs)
+	c.replaceOrgGauge(org, familyMembers, c.instruments.orgMemberCount, int64(len(resp.Members)))
+	return nil
 }
 
-func (c *Collector) collectTeams(ctx context.Context, org string, attrs metric.MeasurementOption) {
+func (c *Collector) collectTeams(ctx context.Context, org string) error {
 	resp, err := c.client.ListTeams(ctx, org)
 	if err != nil {
-		c.logger.Error("failed to list teams", "org", org, "error", err)
-		return
+		return err
 	}
-	c.instruments.orgTeamCount.Record(ctx, int64(len(resp.Teams)), attrs)
+	c.replaceOrgGauge(org, familyTeams, c.instruments.orgTeamCount, int64(len(resp.Teams)))
+	return nil
 }
 
-func (c *Collector) collectEnvironments(ctx context.Context, org string, attrs metric.MeasurementOption) {
+func (c *Collector) collectEnvironments(ctx context.Context, org string) error {
 	resp, err := c.client.ListEnvironments(ctx, org)
 	if err != nil {
-		c.logger.Error("failed to list environments", "org", org, "error", err)
-		return
+		return err
 	}
-	c.instruments.orgEnvironmentCount.Record(ctx, int64(len(resp.Environments)), attrs)
+	c.replaceOrgGauge(org, familyEnvironments, c.instruments.orgEnvironmentCount, int64(len(resp.Environments)))
+	return nil
 }
 
-func (c *Collector) collectPolicyGroups(ctx context.Context, org string, attrs metric.MeasurementOption) {
+// collectPolicies collects the policy group and policy pack metrics, the
+// stacks no policy group governs and the required policy pack checks.
+func (c *Collector) collectPolicies(ctx context.Context, org string) error {
+	groups, groupsErr := c.collectPolicyGroups(ctx, org)
+	packs, packsErr := c.collectPolicyPacks(ctx, org)
+	if groupsErr != nil {
+		return errors.Join(groupsErr, packsErr)
+	}
+
+	details, err := c.getPolicyGroups(ctx, org, groups)
+	if err != nil {
+		return errors.Join(packsErr, err)
+	}
+	stacks, err := c.orgStacks(ctx, org)
+	if err != nil {
+		return errors.Join(packsErr, err)
+	}
+
+	c.recordPolicyCoverage(org, details, stacks)
+	c.recordRequiredPolicyPacks(org, details, packs, stacks)
+	return packsErr
+}
+
+// collectPolicyGroups collects the number of policy groups and the stacks and
+// enabled packs of each group.
+func (c *Collector) collectPolicyGroups(ctx context.Context, org string) ([]client.PolicyGroupInfo, error) {
 	resp, err := c.client.ListPolicyGroups(ctx, org)
 	if err != nil {
-		c.logger.Error("failed to list policy groups", "org", org, "error", err)
-		return
+		return nil, err
+	}
+	c.replaceOrgGauge(org, "policy_groups", c.instruments.orgPolicyGroupCount, int64(len(resp.PolicyGroups)))
+
+	var gauges gaugeBatch
+	for _, g := range resp.PolicyGroups {
+		attrs := []attribute.KeyValue{
+			attribute.String("org", org),
+			attribute.String("policy_group", g.Name),
+		}
+		gauges.addInt64(c.instruments.policyGroupStacks, int64(g.NumStacks), attrs...)
+		gauges.addInt64(c.instruments.policyGroupPacks, int64(g.NumEnabledPolicyPacks), attrs...)
 	}
-	c.instruments.orgPolicyGroupCount.Record(ctx, int64(len(resp.PolicyGroups)), attrs)
+	c.instruments.gauges.replace(orgScope(org, "policy_group_sizes"), &gauges)
+
+	return resp.PolicyGroups, nil
 }
 
-func (c *Collector) collectPolicyPacks(ctx context.Context, org string, attrs metric.MeasurementOption) {
+func (c *Collector) collectPolicyPacks(ctx context.Context, org string) ([]client.PolicyPackInfo, error) {
 	resp, err := c.client.ListPolicyPacks(ctx, org)
 	if err != nil {
-		c.logger.Error("failed to list policy packs", "org", org, "error", err)
-		return
+		return nil, err
 	}
-	c.instruments.orgPolicyPackCount.Record(ctx, int64(len(resp.PolicyPacks)), attrs)
+	c.replaceOrgGauge(org, "policy_packs", c.instruments.orgPolicyPackCount, int64(len(resp.PolicyPacks)))
+	return resp.PolicyPacks, nil
 }
 
-func (c *Collector) collectPolicyViolations(ctx context.Context, org string) {
-	resp, err := c.client.ListPolicyViolations(ctx, org)
+// collectViolations collects the policy results: violations and compliance metadata.
+func (c *Collector) collectViolations(ctx context.Context, org string) error {
+	return errors.Join(
+		c.collectPolicyViolations(ctx, org),
+		c.collectPolicyResultsMetadata(ctx, org),
+	)
+}
+
+func (c *Collector) collectPolicyViolations(ctx context.Context, org string) error {
+	resp, truncated, err := c.client.ListPolicyViolations(ctx, org, max(c.cfg.Pulumi.MaxViolationPages, 1))
 	if err != nil {
-		c.logger.Error("failed to list policy violations", "org", org, "error", err)
-		return
+		return err
 	}
+	violations := resp.PolicyViolations
 
 	counts := make(map[[2]string]int64) // [level, kind] -> count
-	for _, v := range resp.PolicyViolations {
-		level := v.Level
-		if level == "" {
-			level = "unknown"
-		}
-		kind := v.Kind
-		if kind == "" {
-			kind = "unknown"
+	for _, level := range policyLevels {
+		for _, kind := range policyKinds {
+			counts[[2]string{level, kind}] = 0
 		}
-		counts[[2]string{level, kind}]++
+	}
+	for _, v := range violations {
+		counts[[2]string{orUnknown(v.Level), orUnknown(v.Kind)}]++
 	}
 
+	var gauges gaugeBatch
 	for key, count := range counts {
-		c.instruments.orgPolicyViolations.Record(ctx, count, metric.WithAttributes(
+		gauges.addInt64(c.instruments.orgPolicyViolations, count,
 			attribute.String("org", org),
 			attribute.String("level", key[0]),
 			attribute.String("kind", key[1]),
-		))
+		)
 	}
+
+	var truncatedValue int64
+	if truncated {
+		truncatedValue = 1
+		c.logger.Warn("policy violation page cap reached, violations are under-counted",
+			"org", org, "pages", max(c.cfg.Pulumi.MaxViolationPages, 1), "violations", len(violations))
+	}
+	gauges.addInt64(c.instruments.orgViolationsTruncated, truncatedValue, orgAttr(org))
+	c.instruments.gauges.replace(orgScope(org, "policy_violations"), &gauges)
+
+	c.recordViolationBreakdowns(org, violations)
+	c.trackViolationLifecycle(ctx, org, violations, !truncated, time.Now())
+
+	return nil
 }
 
-func (c *Collector) collectPolicyResultsMetadata(ctx context.Context, org string, attrs metric.MeasurementOption) {
+func (c *Collector) collectPolicyResultsMetadata(ctx context.Context, org string) error {
 	resp, err := c.client.GetPolicyResultsMetadata(ctx, org)
 	if err != nil {
-		c.logger.Error("failed to get policy results metadata", "org", org, "error", err)
-		return
+		return err
 	}
-	c.instruments.orgPolicyTotal.Record(ctx, resp.PolicyTotalCount, attrs)
-	c.instruments.orgPolicyWithIssues.Record(ctx, resp.PolicyWithIssuesCount, attrs)
-	c.instruments.orgResourcesTotal.Record(ctx, resp.ResourcesTotalCount, attrs)
-	c.instruments.orgResourcesIssues.Record(ctx, resp.ResourcesWithIssuesCount, attrs)
+
+	var gauges gaugeBatch
+	gauges.addInt64(c.instruments.orgPolicyTotal, resp.PolicyTotalCount, orgAttr(org))
+	gauges.addInt64(c.instruments.orgPolicyWithIssues, resp.PolicyWithIssuesCount, orgAttr(org))
+	gauges.addInt64(c.instruments.orgResourcesTotal, resp.ResourcesTotalCount, orgAttr(org))
+	gauges.addInt64(c.instruments.orgResourcesIssues, resp.ResourcesWithIssuesCount, orgAttr(org))
+	c.instruments.gauges.replace(orgScope(org, "policy_results"), &gauges)
+	return nil
 }
 
-func (c *Collector) collectNeoTasks(ctx context.Context, org string) {
+// collectNeo collects the Pulumi Neo task and token budget metrics.
+func (c *Collector) collectNeo(ctx context.Context, org string) error {
+	return errors.Join(
+		c.collectNeoTasks(ctx, org),
+		c.collectNeoTokenBudget(ctx, org),
+	)
+}
+
+func (c *Collector) collectNeoTasks(ctx context.Context, org string) error {
 	resp, err := c.client.ListNeoTasks(ctx, org)
 	if err != nil {
-		c.logger.Error("failed to list neo tasks", "org", org, "error", err)
-		return
+		return err
 	}
 
 	now := time.Now().UTC()
@@ -131,35 +192,56 @@ func (c *Collector) collectNeoTasks(ctx context.Context, org string) {
 		}
 	}
 
-	orgAttr := metric.WithAttributes(attribute.String("org", org))
-	c.instruments.orgNeoTokensUsedMonth.Record(ctx, tokensMonth, orgAttr)
-	c.instruments.orgNeoTokensUsedTotal.Record(ctx, tokensTotal, orgAttr)
+	var gauges gaugeBatch
+	gauges.addInt64(c.instruments.orgNeoTokensUsedMonth, tokensMonth, orgAttr(org))
+	gauges.addInt64(c.instruments.orgNeoTokensUsedTotal, tokensTotal, orgAttr(org))
 
-	for status, count := range statusCounts {
-		c.instruments.orgNeoTaskCount.Record(ctx, count, metric.WithAttributes(
+	for status, count := range countBuckets(statusCounts, neoTaskStatuses...) {
+		gauges.addInt64(c.instruments.orgNeoTaskCount, count,
 			attribute.String("org", org),
 			attribute.String("status", status),
-		))
+		)
 	}
+	c.instruments.gauges.replace(orgScope(org, "neo_tasks"), &gauges)
+
+	return nil
 }
 
-func (c *Collector) collectNeoTokenBudget(ctx context.Context, org string, attrs metric.MeasurementOption) {
+func (c *Collector) collectNeoTokenBudget(ctx context.Context, org string) error {
 	resp, err := c.client.GetOrgNeoTokenBudget(ctx, org)
 	if err != nil {
-		c.logger.Error("failed to get neo token budget", "org", org, "error", err)
-		return
+		return err
 	}
 	if resp == nil {
-		// Organization has no Neo token budget; nothing to record.
-		return
+		// Organization has no Neo token budget; drop any previously reported budget.
+		c.instruments.gauges.replace(orgScope(org, "neo_budget"), &gaugeBatch{})
+		return nil
 	}
 
-	c.instruments.orgNeoTokenBudgetConsumed.Record(ctx, resp.ConsumedTokens, attrs)
-	c.instruments.orgNeoTokenBudgetAllowance.Record(ctx, resp.EffectiveAllowanceTokens, attrs)
+	var gauges gaugeBatch
+	gauges.addInt64(c.instruments.orgNeoTokenBudgetConsumed, resp.ConsumedTokens, orgAttr(org))
+	gauges.addInt64(c.instruments.orgNeoTokenBudgetAllowance, resp.EffectiveAllowanceTokens, orgAttr(org))
 
 	var exhausted int64
 	if resp.Exhausted {
 		exhausted = 1
 	}
-	c.instruments.orgNeoTokenBudgetExhausted.Record(ctx, exhausted, attrs)
+	gauges.addInt64(c.instruments.orgNeoTokenBudgetExhausted, exhausted, orgAttr(org))
+	c.instruments.gauges.replace(orgScope(org, "neo_budget"), &gauges)
+	return nil
+}
+
+// orUnknown returns s, or "unknown" if s is empty.
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
+
+// replaceOrgGauge sets the single org-labelled value of gauge reported under name.
+func (c *Collector) replaceOrgGauge(org, name string, gauge metric.Int64ObservableGauge, value int64) {
+	var gauges gaugeBatch
+	gauges.addInt64(gauge, value, orgAttr(org))
+	c.instruments.gauges.replace(orgScope(org, name), &gauges)
 }
@@ -0,0 +1,65 @@
+package collector
+
+import (
+	"time"
+
+	"github.com/pulumi-labs/pulumi-exporter/internal/client"
+	"github.com/pulumi-labs/pulumi-exporter/internal/config"
+)
+
+// stackState is what the collector remembers about a stack between cycles.
+type stackState struct {
+	lastUpdate int64     // LastUpdate from ListStacks at the last successful collection
+	inProgress bool      // the newest update had not finished at the last collection
+	collected  time.Time // time of the last successful collection
+}
+
+// pollScheduler assigns stacks to polling tiers by the age of their last
+// update and decides which stacks are due for collection in a cycle.
+type pollScheduler struct {
+	tiers []config.PollTier
+}
+
+func newPollScheduler(cfg config.PollingConfig) *pollScheduler {
+	tiers := cfg.Tiers
+	if len(tiers) == 0 {
+		tiers = config.DefaultPollTiers
+	}
+	return &pollScheduler{tiers: tiers}
+}
+
+// tierNames returns the names of all tiers, in order.
+func (p *pollScheduler) tierNames() []string {
+	names := make([]string, len(p.tiers))
+	for i, tier := range p.tiers {
+		names[i] = tier.Name
+	}
+	return names
+}
+
+// tier returns the index of the tier of stack. Stacks with an update in
+// progress are in the first tier, and stacks that were never updated or are
+// older than every tier's max age are in the last.
+func (p *pollScheduler) tier(stack client.StackSummary, state stackState, now time.Time) int {
+	if state.inProgress {
+		return 0
+	}
+	if stack.LastUpdate > 0 {
+		age := now.Sub(time.Unix(stack.LastUpdate, 0))
+		for i, tier := range p.tiers {
+			if tier.MaxAge == 0 || age <= tier.MaxAge {
+				return i
+			}
+		}
+	}
+	return len(p.tiers) - 1
+}
+
+// due reports whether a stack in tier must be collected: it was never
+// collected successfully, was updated since, or its tier's interval has passed.
+func (p *pollScheduler) due(tier int, stack client.StackSummary, state stackState, known bool, now time.Time) bool {
+	if !known || state.lastUpdate != stack.LastUpdate {
+		return true
+	}
+	return now.Sub(state.collected) >= p.tiers[tier].Interval
+}
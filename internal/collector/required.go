@@ -0,0 +1,154 @@
+package collector
+
+import (
+	"slices"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/pulumi-labs/pulumi-exporter/internal/client"
+	"github.com/pulumi-labs/pulumi-exporter/internal/config"
+)
+
+// requiredPolicyPack is a config.RequiredPolicyPack with compiled stack
+// patterns.
+type requiredPolicyPack struct {
+	name   string
+	orgs   []string
+	stacks []stackPattern
+}
+
+func compileRequiredPolicyPacks(packs []config.RequiredPolicyPack) ([]requiredPolicyPack, error) {
+	compiled := make([]requiredPolicyPack, 0, len(packs))
+	for _, p := range packs {
+		stacks, err := compileStackPatterns(p.Stacks)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, requiredPolicyPack{name: p.Name, orgs: p.Orgs, stacks: stacks})
+	}
+	return compiled, nil
+}
+
+// appliesToOrg reports whether r applies to the stacks of org.
+func (r requiredPolicyPack) appliesToOrg(org string) bool {
+	return len(r.orgs) == 0 || slices.Contains(r.orgs, org)
+}
+
+// appliesTo reports whether stack must have r enabled.
+func (r requiredPolicyPack) appliesTo(stack client.StackSummary) bool {
+	if !r.appliesToOrg(stack.OrgName) {
+		return false
+	}
+	name := stack.OrgName + "/" + stack.ProjectName + "/" + stack.StackName
+	return len(r.stacks) == 0 || matchAny(r.stacks, name)
+}
+
+// requiredPolicyPacks returns the required policy packs that apply to org.
+func (c *Collector) requiredPolicyPacks(org string) []requiredPolicyPack {
+	var packs []requiredPolicyPack
+	for _, r := range c.requiredPacks {
+		if r.appliesToOrg(org) {
+			packs = append(packs, r)
+		}
+	}
+	return packs
+}
+
+// recordRequiredPolicyPacks reports, for every policy pack required in org,
+// the stacks that do not have it enabled in any of their policy groups, and
+// how many published versions the version enabled in each group is behind.
+// Packs enabled in the org default group are enabled for every stack. The
+// versions behind are only reported for packs with published versions.
+func (c *Collector) recordRequiredPolicyPacks(org string, groups []*client.PolicyGroupResponse, packs []client.PolicyPackInfo, stacks []client.StackSummary) {
+	required := c.requiredPolicyPacks(org)
+	names := make(map[string]struct{}, len(required))
+	for _, r := range required {
+		names[r.name] = struct{}{}
+	}
+
+	published := make(map[string][]int64, len(packs))
+	for _, p := range packs {
+		published[p.Name] = p.Versions
+	}
+
+	var gauges gaugeBatch
+	orgWide := make(map[string]struct{})
+	enabled := make(map[[2]string]map[string]struct{})
+	for _, g := range groups {
+		if g.IsOrgDefault {
+			for _, p := range g.AppliedPolicyPacks {
+				orgWide[p.Name] = struct{}{}
+			}
+		}
+		for _, s := range g.Stacks {
+			key := [2]string{s.ProjectName, s.StackName}
+			if enabled[key] == nil {
+				enabled[key] = make(map[string]struct{})
+			}
+			for _, p := range g.AppliedPolicyPacks {
+				enabled[key][p.Name] = struct{}{}
+			}
+		}
+
+		for _, p := range g.AppliedPolicyPacks {
+			versions, ok := published[p.Name]
+			if _, required := names[p.Name]; !required || !ok {
+				continue
+			}
+			gauges.addInt64(c.instruments.requiredPackVersionsBehind, versionsBehind(versions, p.Version),
+				attribute.String("org", org),
+				attribute.String("policy_group", g.Name),
+				attribute.String("policy_pack", p.Name),
+			)
+		}
+	}
+
+	// Every required pack is reported, as 0 when no stack is missing it.
+	missing := make(map[string]int64, len(names))
+	for name := range names {
+		missing[name] = 0
+	}
+	for _, s := range stacks {
+		key := [2]string{s.ProjectName, s.StackName}
+		// A pack required by several rules is only missing once.
+		reported := make(map[string]struct{})
+		for _, r := range required {
+			if _, ok := reported[r.name]; ok || !r.appliesTo(s) {
+				continue
+			}
+			if _, ok := orgWide[r.name]; ok {
+				continue
+			}
+			if _, ok := enabled[key][r.name]; ok {
+				continue
+			}
+			reported[r.name] = struct{}{}
+			missing[r.name]++
+			gauges.addInt64(c.instruments.stackRequiredPackMissing, 1,
+				attribute.String("org", org),
+				attribute.String("project", s.ProjectName),
+				attribute.String("stack", s.StackName),
+				attribute.String("policy_pack", r.name),
+			)
+		}
+	}
+	for name, count := range missing {
+		gauges.addInt64(c.instruments.requiredPackMissingStacks, count,
+			attribute.String("org", org),
+			attribute.String("policy_pack", name),
+		)
+	}
+
+	c.instruments.gauges.replace(orgScope(org, "required_policy_packs"), &gauges)
+}
+
+// versionsBehind returns the number of versions newer than version.
+func versionsBehind(versions []int64, version int64) int64 {
+	var behind int64
+	for _, v := range versions {
+		if v > version {
+			behind++
+		}
+	}
+	return behind
+}
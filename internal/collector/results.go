@@ -0,0 +1,84 @@
+package collector
+
+import (
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/pulumi-labs/pulumi-exporter/internal/client"
+)
+
+// resultKinds are the update kinds whose latest result and failure streak are
+// reported per stack.
+var resultKinds = []string{"update", "destroy"}
+
+// updateResults is the state of the updates of one kind of a stack.
+type updateResults struct {
+	latest              client.UpdateInfo // newest update
+	lastSucceeded       client.UpdateInfo // newest successful update
+	consecutiveFailures int               // failed updates since the last successful one
+}
+
+// computeResults returns the state of the updates of kind, oldest first, and
+// whether there are any. Unfinished updates do not end a failure streak.
+func computeResults(updates []client.UpdateInfo, kind string) (updateResults, bool) {
+	var r updateResults
+	var found bool
+
+	for _, u := range updates {
+		if u.Kind != kind {
+			continue
+		}
+		found = true
+		r.latest = u
+
+		switch u.Result {
+		case "failed":
+			r.consecutiveFailures++
+		case "succeeded":
+			r.lastSucceeded = u
+			r.consecutiveFailures = 0
+		}
+	}
+
+	return r, found
+}
+
+// addResultGauges adds the latest result, failure streak and last attempted
+// and successful times of every result kind in a stack's update history.
+func (c *Collector) addResultGauges(gauges *gaugeBatch, updates []client.UpdateInfo, stackAttrs []attribute.KeyValue) {
+	for _, kind := range resultKinds {
+		r, ok := computeResults(updates, kind)
+		if !ok {
+			continue
+		}
+		attrs := append(stackAttrs[:len(stackAttrs):len(stackAttrs)], attribute.String("kind", kind))
+
+		gauges.addInt64(c.instruments.stackLastResult, 1, append(attrs[:len(attrs):len(attrs)], attribute.String("result", r.latest.Result))...)
+		gauges.addInt64(c.instruments.stackFailureStreak, int64(r.consecutiveFailures), attrs...)
+		gauges.addFloat64(c.instruments.stackLastAttempted, float64(r.latest.StartTime), attrs...)
+		if r.lastSucceeded.EndTime > 0 {
+			gauges.addFloat64(c.instruments.stackLastSucceeded, float64(r.lastSucceeded.EndTime), attrs...)
+		}
+	}
+}
+
+// addInProgressGauges adds the age of the oldest unfinished update of every
+// kind in a stack's update history.
+func (c *Collector) addInProgressGauges(gauges *gaugeBatch, updates []client.UpdateInfo, now time.Time, stackAttrs []attribute.KeyValue) {
+	oldest := make(map[string]int64)
+	for _, u := range updates {
+		if updateFinished(u) || u.StartTime == 0 {
+			continue
+		}
+		if start, ok := oldest[u.Kind]; !ok || u.StartTime < start {
+			oldest[u.Kind] = u.StartTime
+		}
+	}
+
+	for kind, start := range oldest {
+		age := max(now.Unix()-start, 0)
+		gauges.addFloat64(c.instruments.stackInProgressAge, float64(age),
+			append(stackAttrs[:len(stackAttrs):len(stackAttrs)], attribute.String("kind", kind))...)
+	}
+}
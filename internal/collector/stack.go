@@ -2,59 +2,119 @@ package collector
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
 
 	"github.com/pulumi-labs/pulumi-exporter/internal/client"
+	"github.com/pulumi-labs/pulumi-exporter/internal/config"
 )
 
-func (c *Collector) collectStack(ctx context.Context, stack client.StackSummary) {
-	stackAttrs := metric.WithAttributes(
+// collectStack records the metrics of a single stack. It returns an error if
+// any of the stack's API calls failed; failures are logged here. The stack's
+// gauges are only replaced when every call succeeded, so a failure keeps the
+// values of the previous cycle. In incremental mode the resource count is
+// taken from the stack summary when present.
+func (c *Collector) collectStack(ctx context.Context, stack client.StackSummary) error {
+	stackAttrs := []attribute.KeyValue{
 		attribute.String("org", stack.OrgName),
 		attribute.String("project", stack.ProjectName),
 		attribute.String("stack", stack.StackName),
-	)
+	}
+	var gauges gaugeBatch
 
 	// Resource count.
-	rc, err := c.client.GetResourceCount(ctx, stack.OrgName, stack.ProjectName, stack.StackName)
-	if err != nil {
-		c.logger.Error("failed to get resource count",
-			"org", stack.OrgName, "project", stack.ProjectName, "stack", stack.StackName, "error", err)
+	var rcErr error
+	if c.cfg.Pulumi.Incremental && stack.ResourceCount != nil {
+		gauges.addInt64(c.instruments.stackResourceCount, int64(*stack.ResourceCount), stackAttrs...)
 	} else {
-		c.instruments.stackResourceCount.Record(ctx, int64(rc.Count), stackAttrs)
+		var rc *client.ResourceCountResponse
+		rc, rcErr = c.client.GetResourceCount(ctx, stack.OrgName, stack.ProjectName, stack.StackName)
+		if rcErr != nil {
+			c.logger.Error("failed to get resource count",
+				"org", stack.OrgName, "project", stack.ProjectName, "stack", stack.StackName, "error", rcErr)
+		} else {
+			gauges.addInt64(c.instruments.stackResourceCount, int64(rc.Count), stackAttrs...)
+		}
 	}
 
-	// Updates.
-	updates, err := c.client.ListUpdates(ctx, stack.OrgName, stack.ProjectName, stack.StackName, 1, 100)
-	if err != nil {
-		c.logger.Error("failed to list updates",
-			"org", stack.OrgName, "project", stack.ProjectName, "stack", stack.StackName, "error", err)
-		return
+	// Latest deployment.
+	var deployErr error
+	if c.collectsStackDeployments(stack) {
+		deployErr = c.collectStackDeployment(ctx, stack, &gauges, stackAttrs)
+		if deployErr != nil {
+			c.logger.Error("failed to list stack deployments",
+				"org", stack.OrgName, "project", stack.ProjectName, "stack", stack.StackName, "error", deployErr)
+		}
 	}
 
 	stackKey := stack.OrgName + "/" + stack.ProjectName + "/" + stack.StackName
 
 	c.mu.Lock()
-	lastVersion := c.lastSeenVersion[stackKey]
+	lastVersion, seen := c.lastSeenVersion[stackKey]
+	pending := c.pendingUpdates[stackKey]
 	c.mu.Unlock()
 
+	// Stacks without a checkpoint are seeded according to the configured policy.
+	// With the "latest" policy the existing history only advances the checkpoint
+	// and is not added to the update counters.
+	countHistory := seen || c.cfg.Checkpoint.SeedPolicy != config.SeedPolicyLatest
+
+	// Updates still in progress at a previous collection are fetched again
+	// until they finish.
+	fromVersion := lastVersion
+	for version := range pending {
+		fromVersion = min(fromVersion, version-1)
+	}
+
+	// Updates.
+	updates, err := c.listNewUpdates(ctx, stack, fromVersion, lastVersion, seen, countHistory)
+	if err != nil {
+		c.logger.Error("failed to list updates",
+			"org", stack.OrgName, "project", stack.ProjectName, "stack", stack.StackName, "error", err)
+		return errors.Join(rcErr, deployErr, err)
+	}
+
+	c.history.merge(stackKey, updates, time.Now())
+
 	var latestEndTime int64
 	var maxVersion int
+	nextPending := make(map[int]struct{})
 
-	for _, update := range updates.Updates {
-		// Only process updates newer than what we've seen.
-		if update.Version <= lastVersion {
+	for _, update := range updates {
+		// Only process updates newer than what we've seen, or that were
+		// still in progress when last seen.
+		_, wasPending := pending[update.Version]
+		if update.Version <= lastVersion && !wasPending {
 			continue
 		}
 
-		updateAttrs := metric.WithAttributes(
+		if update.EndTime > latestEndTime {
+			latestEndTime = update.EndTime
+		}
+		if update.Version > maxVersion {
+			maxVersion = update.Version
+		}
+
+		if !countHistory {
+			continue
+		}
+
+		// Unfinished updates are counted once they finish.
+		if !updateFinished(update) {
+			nextPending[update.Version] = struct{}{}
+			continue
+		}
+
+		updateAttrs := metric.WithAttributes(append([]attribute.KeyValue{
 			attribute.String("org", stack.OrgName),
 			attribute.String("project", stack.ProjectName),
 			attribute.String("stack", stack.StackName),
 			attribute.String("kind", update.Kind),
 			attribute.String("result", update.Result),
-		)
+		}, c.updateEnvironmentAttrs(update)...)...)
 
 		// Duration.
 		if update.EndTime > 0 && update.StartTime > 0 {
@@ -76,30 +136,115 @@ func (c *Collector) collectStack(ctx context.Context, stack client.StackSummary)
 			)
 			c.instruments.updateResourceChanges.Add(ctx, int64(count), changeAttrs)
 		}
+	}
 
-		// Track latest end time.
-		if update.EndTime > latestEndTime {
-			latestEndTime = update.EndTime
+	// Update last seen version and pending updates under lock.
+	c.mu.Lock()
+	if maxVersion > c.lastSeenVersion[stackKey] {
+		c.lastSeenVersion[stackKey] = maxVersion
+	}
+	if len(nextPending) > 0 {
+		c.pendingUpdates[stackKey] = nextPending
+	} else {
+		delete(c.pendingUpdates, stackKey)
+	}
+	c.mu.Unlock()
+
+	// Record last update timestamp.
+	if latestEndTime > 0 {
+		gauges.addFloat64(c.instruments.stackLastUpdate, float64(latestEndTime), stackAttrs...)
+	} else if stack.LastUpdate > 0 {
+		gauges.addFloat64(c.instruments.stackLastUpdate, float64(stack.LastUpdate), stackAttrs...)
+	}
+
+	if err := errors.Join(rcErr, deployErr); err != nil {
+		return err
+	}
+
+	c.instruments.gauges.replace(stackScope(stackKey), &gauges)
+
+	c.mu.Lock()
+	c.stackStates[stackKey] = stackState{
+		lastUpdate: stack.LastUpdate,
+		inProgress: updateInProgress(updates) || len(nextPending) > 0,
+		collected:  time.Now(),
+	}
+	c.mu.Unlock()
+	return nil
+}
+
+// updatesPageSize is the number of updates requested per ListUpdates page.
+const updatesPageSize = 100
+
+// listNewUpdates pages backwards through a stack's update history, newest
+// first, until it reaches fromVersion or the configured page cap. fromVersion
+// is lastVersion or lower when older updates are still in progress. When the
+// cap is hit before lastVersion on a stack with a checkpoint, the number of
+// updates that could not be fetched is added to pulumi_update_skipped_total.
+func (c *Collector) listNewUpdates(ctx context.Context, stack client.StackSummary, fromVersion, lastVersion int, seen, countHistory bool) ([]client.UpdateInfo, error) {
+	maxPages := c.cfg.Pulumi.MaxUpdatePages
+	if maxPages < 1 {
+		maxPages = 1
+	}
+	// Seeding from the latest version only needs the newest page.
+	if !countHistory {
+		maxPages = 1
+	}
+
+	var updates []client.UpdateInfo
+	for page := 1; page <= maxPages; page++ {
+		resp, err := c.client.ListUpdates(ctx, stack.OrgName, stack.ProjectName, stack.StackName, page, updatesPageSize)
+		if err != nil {
+			return nil, err
 		}
+		updates = append(updates, resp.Updates...)
 
-		if update.Version > maxVersion {
-			maxVersion = update.Version
+		oldest := oldestVersion(resp.Updates)
+		if len(resp.Updates) < updatesPageSize || oldest <= fromVersion+1 {
+			return updates, nil
+		}
+
+		if page == maxPages && seen && oldest > lastVersion+1 {
+			skipped := oldest - lastVersion - 1
+			c.logger.Warn("update page cap reached, skipping older updates",
+				"org", stack.OrgName, "project", stack.ProjectName, "stack", stack.StackName,
+				"pages", maxPages, "skipped", skipped)
+			c.instruments.updateSkipped.Add(ctx, int64(skipped), metric.WithAttributes(
+				attribute.String("org", stack.OrgName),
+				attribute.String("project", stack.ProjectName),
+				attribute.String("stack", stack.StackName),
+			))
 		}
 	}
 
-	// Update last seen version under lock.
-	if maxVersion > 0 {
-		c.mu.Lock()
-		if maxVersion > c.lastSeenVersion[stackKey] {
-			c.lastSeenVersion[stackKey] = maxVersion
+	return updates, nil
+}
+
+// updateInProgress reports whether the newest of updates has not finished.
+func updateInProgress(updates []client.UpdateInfo) bool {
+	var newest client.UpdateInfo
+	for _, u := range updates {
+		if u.Version > newest.Version {
+			newest = u
 		}
-		c.mu.Unlock()
 	}
+	return newest.Version > 0 && newest.EndTime == 0
+}
 
-	// Record last update timestamp.
-	if latestEndTime > 0 {
-		c.instruments.stackLastUpdate.Record(ctx, float64(latestEndTime), stackAttrs)
-	} else if stack.LastUpdate > 0 {
-		c.instruments.stackLastUpdate.Record(ctx, float64(stack.LastUpdate), stackAttrs)
+// updateFinished reports whether update has a final result. Every result but
+// in-progress is final, so updates that never started or were cancelled do
+// not stay pending.
+func updateFinished(update client.UpdateInfo) bool {
+	return update.Result != "in-progress"
+}
+
+// oldestVersion returns the lowest version in updates, or 0 if there are none.
+func oldestVersion(updates []client.UpdateInfo) int {
+	oldest := 0
+	for _, u := range updates {
+		if oldest == 0 || u.Version < oldest {
+			oldest = u.Version
+		}
 	}
+	return oldest
 }
@@ -0,0 +1,156 @@
+package collector
+
+import (
+	"maps"
+	"sync"
+	"time"
+)
+
+// FamilyStatus describes the latest collection of one metric family for an org.
+type FamilyStatus struct {
+	LastSuccess         time.Time `json:"last_success,omitzero"`
+	LastError           string    `json:"last_error,omitempty"`
+	LastErrorTime       time.Time `json:"last_error_time,omitzero"`
+	LastDurationSeconds float64   `json:"last_duration_seconds"`
+	Disabled            bool      `json:"disabled,omitempty"`
+}
+
+// CycleStatus describes the collection cycles of one metric family.
+type CycleStatus struct {
+	Count           int       `json:"count"`
+	LastStart       time.Time `json:"last_start,omitzero"`
+	LastEnd         time.Time `json:"last_end,omitzero"`
+	DurationSeconds float64   `json:"last_duration_seconds"`
+}
+
+// Status is a point-in-time view of the collector's health, served as JSON on /status.
+type Status struct {
+	Ready               bool                               `json:"ready"`
+	Healthy             bool                               `json:"healthy"`
+	Cycles              map[string]CycleStatus             `json:"cycles"`
+	ListStacksSuccesses int                                `json:"list_stacks_consecutive_successes"`
+	LastListStacksError string                             `json:"last_list_stacks_error,omitempty"`
+	Orgs                map[string]map[string]FamilyStatus `json:"orgs"`
+}
+
+// statusTracker records collection progress for readiness, liveness and /status.
+type statusTracker struct {
+	mu                  sync.Mutex
+	cycles              map[string]CycleStatus
+	listStacksSuccesses int
+	listStacksErr       string
+	families            map[string]map[string]FamilyStatus
+}
+
+func newStatusTracker() *statusTracker {
+	return &statusTracker{
+		cycles:   make(map[string]CycleStatus),
+		families: make(map[string]map[string]FamilyStatus),
+	}
+}
+
+func (s *statusTracker) startCycle(family string, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cs := s.cycles[family]
+	cs.LastStart = now
+	s.cycles[family] = cs
+}
+
+func (s *statusTracker) endCycle(family string, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cs := s.cycles[family]
+	cs.Count++
+	cs.LastEnd = now
+	cs.DurationSeconds = now.Sub(cs.LastStart).Seconds()
+	s.cycles[family] = cs
+}
+
+func (s *statusTracker) recordListStacks(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		s.listStacksSuccesses = 0
+		s.listStacksErr = err.Error()
+		return
+	}
+	s.listStacksSuccesses++
+}
+
+func (s *statusTracker) recordFamily(org, family string, start, end time.Time, err error, disabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.families[org] == nil {
+		s.families[org] = make(map[string]FamilyStatus)
+	}
+	fs := s.families[org][family]
+	fs.LastDurationSeconds = end.Sub(start).Seconds()
+	fs.Disabled = disabled
+	if err != nil {
+		fs.LastError = err.Error()
+		fs.LastErrorTime = end
+	} else {
+		fs.LastSuccess = end
+	}
+	s.families[org][family] = fs
+}
+
+// ready reports whether the first stacks collection has completed and
+// ListStacks succeeded in each of the last n cycles (or every cycle so far,
+// if fewer). A cycle still running after ListStacks does not count yet.
+func (s *statusTracker) ready(n int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	count := s.cycles[familyStacks].Count
+	return count > 0 && s.listStacksSuccesses > 0 && s.listStacksSuccesses >= min(n, count)
+}
+
+// anyCycleCompleted reports whether any family completed a collection cycle.
+func (s *statusTracker) anyCycleCompleted() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, cs := range s.cycles {
+		if cs.Count > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// healthy reports whether the collection loop of family is making progress:
+// a running cycle must finish within maxCycle, and a new cycle must start
+// within maxIdle of the previous one ending.
+func (s *statusTracker) healthy(family string, now time.Time, maxCycle, maxIdle time.Duration) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cs := s.cycles[family]
+	switch {
+	case cs.LastStart.IsZero():
+		// The loop has not started yet.
+		return true
+	case cs.LastStart.After(cs.LastEnd):
+		return now.Sub(cs.LastStart) <= maxCycle
+	default:
+		return now.Sub(cs.LastEnd) <= maxIdle
+	}
+}
+
+func (s *statusTracker) snapshot() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	orgs := make(map[string]map[string]FamilyStatus, len(s.families))
+	for org, families := range s.families {
+		orgs[org] = maps.Clone(families)
+	}
+
+	return Status{
+		Cycles:              maps.Clone(s.cycles),
+		ListStacksSuccesses: s.listStacksSuccesses,
+		LastListStacksError: s.listStacksErr,
+		Orgs:                orgs,
+	}
+}
@@ -0,0 +1,175 @@
+package collector
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/pulumi-labs/pulumi-exporter/internal/client"
+)
+
+// otherBucket is the label value of the violations beyond a breakdown's top-N
+// limit, summed into one series.
+const otherBucket = "__other__"
+
+// violationKey holds the label values of one series of a violation breakdown.
+type violationKey [2]string
+
+// recordViolationBreakdowns reports the violations of org by policy, by stack
+// and by resource type, each limited to the configured top N.
+func (c *Collector) recordViolationBreakdowns(org string, violations []client.PolicyViolation) {
+	byPolicy := make(map[violationKey]int64)
+	byStack := make(map[violationKey]int64)
+	byType := make(map[violationKey]int64)
+	for _, v := range violations {
+		byPolicy[violationKey{v.PolicyPack, v.PolicyName}]++
+		byStack[violationKey{v.ProjectName, v.StackName}]++
+		byType[violationKey{v.ResourceType}]++
+	}
+
+	limits := c.cfg.Pulumi.Violations
+	c.replaceViolationBreakdown(org, "policy_violations_by_policy", c.instruments.orgViolationsByPolicy,
+		byPolicy, limits.TopPolicies, "policy_pack", "policy")
+	c.replaceViolationBreakdown(org, "policy_violations_by_stack", c.instruments.orgViolationsByStack,
+		byStack, limits.TopStacks, "project", "stack")
+	c.replaceViolationBreakdown(org, "policy_violations_by_resource_type", c.instruments.orgViolationsByType,
+		byType, limits.TopResourceTypes, "resource_type")
+}
+
+// replaceViolationBreakdown reports the limit largest counts of a breakdown
+// under name, with the rest summed into the other bucket. A limit of 0
+// reports nothing.
+func (c *Collector) replaceViolationBreakdown(org, name string, gauge metric.Int64ObservableGauge, counts map[violationKey]int64, limit int, labels ...string) {
+	var gauges gaugeBatch
+	if limit > 0 {
+		top, other := topViolations(counts, limit)
+		for key, count := range top {
+			gauges.addInt64(gauge, count, violationAttrs(org, key, labels)...)
+		}
+		if other > 0 {
+			gauges.addInt64(gauge, other, violationAttrs(org, violationKey{otherBucket, otherBucket}, labels)...)
+		}
+	}
+	c.instruments.gauges.replace(orgScope(org, name), &gauges)
+}
+
+// topViolations returns the n largest counts, ties broken by key, and the sum
+// of the others.
+func topViolations(counts map[violationKey]int64, n int) (map[violationKey]int64, int64) {
+	keys := make([]violationKey, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b violationKey) int {
+		if c := cmp.Compare(counts[b], counts[a]); c != 0 {
+			return c
+		}
+		return cmp.Or(cmp.Compare(a[0], b[0]), cmp.Compare(a[1], b[1]))
+	})
+
+	top := make(map[violationKey]int64, min(n, len(keys)))
+	var other int64
+	for i, key := range keys {
+		if i < n {
+			top[key] = counts[key]
+		} else {
+			other += counts[key]
+		}
+	}
+	return top, other
+}
+
+// violationAttrs returns the attributes of the breakdown series of key.
+func violationAttrs(org string, key violationKey, labels []string) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{attribute.String("org", org)}
+	for i, label := range labels {
+		attrs = append(attrs, attribute.String(label, key[i]))
+	}
+	return attrs
+}
+
+// openViolation is a policy violation that was open at the last collection.
+type openViolation struct {
+	pack      string
+	level     string
+	firstSeen time.Time
+}
+
+// trackViolationLifecycle compares the violations of org with those open at
+// the previous collection. It counts the new and resolved violations, records
+// the time to remediate of the resolved ones and reports the age of the
+// oldest open violation by pack and level. The first collection of an org
+// only records the open violations. When violations is incomplete, the
+// violations missing from it are kept open instead of being resolved.
+func (c *Collector) trackViolationLifecycle(ctx context.Context, org string, violations []client.PolicyViolation, complete bool, now time.Time) {
+	c.mu.Lock()
+	prev, seen := c.openViolations[org]
+	c.mu.Unlock()
+
+	next := make(map[string]openViolation, len(violations))
+	for _, v := range violations {
+		if v.ID == "" {
+			continue
+		}
+		if o, ok := prev[v.ID]; ok {
+			next[v.ID] = o
+			continue
+		}
+
+		firstSeen := now
+		if !v.ObservedAt.IsZero() && v.ObservedAt.Before(now) {
+			firstSeen = v.ObservedAt
+		}
+		o := openViolation{pack: v.PolicyPack, level: orUnknown(v.Level), firstSeen: firstSeen}
+		next[v.ID] = o
+
+		if seen {
+			c.instruments.violationsOpened.Add(ctx, 1, metric.WithAttributes(o.attrs(org)...))
+		}
+	}
+
+	for id, o := range prev {
+		if _, ok := next[id]; ok {
+			continue
+		}
+		if !complete {
+			next[id] = o
+			continue
+		}
+		attrs := metric.WithAttributes(o.attrs(org)...)
+		c.instruments.violationsResolved.Add(ctx, 1, attrs)
+		c.instruments.violationRemediation.Record(ctx, now.Sub(o.firstSeen).Seconds(), attrs)
+	}
+
+	c.mu.Lock()
+	c.openViolations[org] = next
+	c.mu.Unlock()
+
+	oldest := make(map[violationKey]time.Time)
+	for _, o := range next {
+		key := violationKey{o.pack, o.level}
+		if first, ok := oldest[key]; !ok || o.firstSeen.Before(first) {
+			oldest[key] = o.firstSeen
+		}
+	}
+
+	var gauges gaugeBatch
+	for key, first := range oldest {
+		gauges.addFloat64(c.instruments.violationOldestAge, now.Sub(first).Seconds(),
+			violationAttrs(org, key, []string{"policy_pack", "level"})...)
+	}
+	c.instruments.gauges.replace(orgScope(org, "policy_violation_age"), &gauges)
+}
+
+// attrs returns the attributes of the lifecycle metrics of o.
+func (o openViolation) attrs(org string) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("org", org),
+		attribute.String("policy_pack", o.pack),
+		attribute.String("level", o.level),
+	}
+}
@@ -4,11 +4,14 @@ package config
 import (
 	"fmt"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/alecthomas/kingpin/v2"
 	"gopkg.in/yaml.v3"
+
+	"github.com/pulumi-labs/pulumi-exporter/internal/checkpoint"
 )
 
 const (
@@ -16,23 +19,185 @@ const (
 	protocolGRPC         = "grpc"
 )
 
+// Seed policies for stacks that have no checkpoint yet.
+const (
+	// SeedPolicyHistory counts every update already in the stack's history.
+	SeedPolicyHistory = "history"
+	// SeedPolicyLatest starts counting from the stack's latest version.
+	SeedPolicyLatest = "latest"
+)
+
+// Labels from the update environment that can be added to update metrics.
+// Each has a small number of values.
+const (
+	UpdateLabelCLIVersion = "cli_version"
+	UpdateLabelExecKind   = "exec_kind"
+	UpdateLabelCISystem   = "ci_system"
+)
+
+// UpdateLabels lists the supported update environment labels.
+var UpdateLabels = []string{UpdateLabelCLIVersion, UpdateLabelExecKind, UpdateLabelCISystem}
+
+// Metric families. Each family groups the API calls and metrics of one area of
+// Pulumi Cloud and can be disabled or scheduled on its own interval.
+const (
+	FamilyStacks       = "stacks"
+	FamilyDeployments  = "deployments"
+	FamilyMembers      = "members"
+	FamilyTeams        = "teams"
+	FamilyEnvironments = "environments"
+	FamilyPolicies     = "policies"
+	FamilyViolations   = "violations"
+	FamilyNeo          = "neo"
+)
+
+// Families lists every metric family in collection order.
+var Families = []string{
+	FamilyStacks,
+	FamilyDeployments,
+	FamilyMembers,
+	FamilyTeams,
+	FamilyEnvironments,
+	FamilyPolicies,
+	FamilyViolations,
+	FamilyNeo,
+}
+
 // Config holds the complete application configuration.
 type Config struct {
-	Pulumi    PulumiConfig    `yaml:"pulumi"`
-	Exporters ExportersConfig `yaml:"otlp"`
+	Pulumi     PulumiConfig     `yaml:"pulumi"`
+	Collectors CollectorsConfig `yaml:"collectors"`
+	Exporters  ExportersConfig  `yaml:"otlp"`
+	Prometheus PrometheusConfig `yaml:"prometheus"`
+	Checkpoint CheckpointConfig `yaml:"checkpoint"`
+	Web        WebConfig        `yaml:"web"`
 }
 
 // PulumiConfig holds Pulumi Cloud API configuration.
 type PulumiConfig struct {
-	AccessToken     string        `yaml:"access-token"`
-	APIURL          string        `yaml:"api-url"`
-	Organizations   []string      `yaml:"organizations"`
-	CollectInterval time.Duration `yaml:"collect-interval"`
-	MaxConcurrency  int           `yaml:"max-concurrency"`
+	AccessToken        string        `yaml:"access-token"`
+	APIURL             string        `yaml:"api-url"`
+	Organizations      []string      `yaml:"organizations"`
+	CollectInterval    time.Duration `yaml:"collect-interval"`
+	MaxConcurrency     int           `yaml:"max-concurrency"`
+	MaxUpdatePages     int           `yaml:"max-update-pages"`
+	MaxDeploymentPages int           `yaml:"max-deployment-pages"`
+	MaxViolationPages  int           `yaml:"max-violation-pages"`
+	MaxRetries         int           `yaml:"max-retries"`
+	RetryBudget        int           `yaml:"retry-budget"`
+	RateLimit          float64       `yaml:"rate-limit"`
+	RateBurst          int           `yaml:"rate-burst"`
+	Incremental        bool          `yaml:"incremental"`
+	DeploymentStacks   []string      `yaml:"deployment-stacks"`
+	UpdateLabels       []string      `yaml:"update-labels"`
+
+	StackFilter StackFilterConfig `yaml:"stack-filter"`
+	Polling     PollingConfig     `yaml:"polling"`
+	Violations  ViolationsConfig  `yaml:"violations"`
+
+	RequiredPolicyPacks []RequiredPolicyPack `yaml:"required-policy-packs"`
+}
+
+// StackFilterConfig selects the stacks that are collected. Patterns are globs
+// of the form "project/stack" or "org/project/stack", or regular expressions
+// on "org/project/stack" when prefixed with "re:".
+type StackFilterConfig struct {
+	Include []string      `yaml:"include"`
+	Exclude []string      `yaml:"exclude"`
+	MaxAge  time.Duration `yaml:"max-age"`
+}
+
+// PollingConfig configures adaptive polling, which collects recently active
+// stacks every cycle and backs dormant stacks off to longer intervals.
+type PollingConfig struct {
+	Adaptive bool       `yaml:"adaptive"`
+	Tiers    []PollTier `yaml:"tiers"`
+}
+
+// PollTier polls the stacks last updated within MaxAge once every Interval.
+// A zero MaxAge matches every stack, and a zero Interval polls every cycle.
+// Stacks are placed in the first matching tier.
+type PollTier struct {
+	Name     string        `yaml:"name"`
+	MaxAge   time.Duration `yaml:"max-age"`
+	Interval time.Duration `yaml:"interval"`
+}
+
+// DefaultPollTiers are used when adaptive polling is enabled without tiers.
+var DefaultPollTiers = []PollTier{
+	{Name: "active", MaxAge: 24 * time.Hour},
+	{Name: "recent", MaxAge: 30 * 24 * time.Hour, Interval: time.Hour},
+	{Name: "dormant", Interval: 24 * time.Hour},
+}
+
+// ViolationsConfig limits the policy violation breakdowns to the policies,
+// stacks and resource types with the most violations. A limit of 0 disables
+// the breakdown.
+type ViolationsConfig struct {
+	TopPolicies      int `yaml:"top-policies"`
+	TopStacks        int `yaml:"top-stacks"`
+	TopResourceTypes int `yaml:"top-resource-types"`
+}
+
+// RequiredPolicyPack requires a policy pack to be enabled, through any of
+// their policy groups, on the stacks of Orgs that match one of Stacks. Empty
+// Orgs and Stacks match every org and stack. Stacks takes the same patterns
+// as the stack filter.
+type RequiredPolicyPack struct {
+	Name   string   `yaml:"name"`
+	Orgs   []string `yaml:"orgs"`
+	Stacks []string `yaml:"stacks"`
+}
+
+// CollectorsConfig holds the per-family collection settings.
+type CollectorsConfig struct {
+	Stacks       CollectorConfig `yaml:"stacks"`
+	Deployments  CollectorConfig `yaml:"deployments"`
+	Members      CollectorConfig `yaml:"members"`
+	Teams        CollectorConfig `yaml:"teams"`
+	Environments CollectorConfig `yaml:"environments"`
+	Policies     CollectorConfig `yaml:"policies"`
+	Violations   CollectorConfig `yaml:"violations"`
+	Neo          CollectorConfig `yaml:"neo"`
+}
+
+// CollectorConfig holds the collection settings of one metric family. A zero
+// Interval uses the global collect interval, and a zero Timeout uses 90% of
+// the family's interval with a 10s minimum.
+type CollectorConfig struct {
+	Disabled bool          `yaml:"disabled"`
+	Interval time.Duration `yaml:"interval"`
+	Timeout  time.Duration `yaml:"timeout"`
+}
+
+// Family returns the settings of the named metric family, or nil if there is
+// no such family.
+func (c *CollectorsConfig) Family(name string) *CollectorConfig {
+	switch name {
+	case FamilyStacks:
+		return &c.Stacks
+	case FamilyDeployments:
+		return &c.Deployments
+	case FamilyMembers:
+		return &c.Members
+	case FamilyTeams:
+		return &c.Teams
+	case FamilyEnvironments:
+		return &c.Environments
+	case FamilyPolicies:
+		return &c.Policies
+	case FamilyViolations:
+		return &c.Violations
+	case FamilyNeo:
+		return &c.Neo
+	default:
+		return nil
+	}
 }
 
 // ExportersConfig holds exporter configuration.
 type ExportersConfig struct {
+	Disabled bool              `yaml:"disabled"`
 	Endpoint string            `yaml:"endpoint"`
 	URLPath  string            `yaml:"url-path"`
 	Protocol string            `yaml:"protocol"`
@@ -40,6 +205,23 @@ type ExportersConfig struct {
 	Headers  map[string]string `yaml:"headers"`
 }
 
+// PrometheusConfig holds configuration for the Prometheus /metrics endpoint.
+type PrometheusConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// CheckpointConfig holds configuration for persisting per-stack update checkpoints.
+type CheckpointConfig struct {
+	Store      string `yaml:"store"`
+	Path       string `yaml:"path"`
+	SeedPolicy string `yaml:"seed-policy"`
+}
+
+// WebConfig holds configuration for the health check and status endpoints.
+type WebConfig struct {
+	ReadyCycles int `yaml:"ready-cycles"`
+}
+
 // RegisterFlags registers CLI flags on the given kingpin application and returns a Config.
 // Call this before kingpin.Parse(). After Parse(), the Config will be populated with
 // flag values, env var overrides, and defaults.
@@ -69,6 +251,96 @@ func RegisterFlags(app *kingpin.Application) *Config {
 		Envar("PULUMI_MAX_CONCURRENCY").
 		IntVar(&cfg.Pulumi.MaxConcurrency)
 
+	app.Flag("pulumi.max-update-pages", "Maximum number of update pages (100 updates each) fetched per stack and cycle.").
+		Default("10").
+		Envar("PULUMI_MAX_UPDATE_PAGES").
+		IntVar(&cfg.Pulumi.MaxUpdatePages)
+
+	app.Flag("pulumi.max-deployment-pages", "Maximum number of deployment pages (100 deployments each) fetched per organization and cycle.").
+		Default("5").
+		Envar("PULUMI_MAX_DEPLOYMENT_PAGES").
+		IntVar(&cfg.Pulumi.MaxDeploymentPages)
+
+	app.Flag("pulumi.max-violation-pages", "Maximum number of policy violation pages fetched per organization and cycle.").
+		Default("10").
+		Envar("PULUMI_MAX_VIOLATION_PAGES").
+		IntVar(&cfg.Pulumi.MaxViolationPages)
+
+	app.Flag("pulumi.max-retries", "Maximum number of retries for transient Pulumi API failures (429 and 5xx).").
+		Default("3").
+		Envar("PULUMI_MAX_RETRIES").
+		IntVar(&cfg.Pulumi.MaxRetries)
+
+	app.Flag("pulumi.retry-budget", "Maximum retries per API endpoint per minute (0 for unlimited).").
+		Default("30").
+		Envar("PULUMI_RETRY_BUDGET").
+		IntVar(&cfg.Pulumi.RetryBudget)
+
+	app.Flag("pulumi.rate-limit", "Maximum Pulumi API requests per second across all endpoints (0 for unlimited).").
+		Default("0").
+		Envar("PULUMI_RATE_LIMIT").
+		Float64Var(&cfg.Pulumi.RateLimit)
+
+	app.Flag("pulumi.rate-burst", "Number of API requests allowed to burst above the rate limit.").
+		Default("10").
+		Envar("PULUMI_RATE_BURST").
+		IntVar(&cfg.Pulumi.RateBurst)
+
+	app.Flag("pulumi.incremental", "Only collect stacks whose last update changed since the previous cycle, and take resource counts from ListStacks.").
+		Default("false").
+		Envar("PULUMI_INCREMENTAL").
+		BoolVar(&cfg.Pulumi.Incremental)
+
+	app.Flag("pulumi.adaptive-polling", "Poll recently updated stacks every cycle and dormant stacks less often (tiers are set in the config file).").
+		Default("false").
+		Envar("PULUMI_ADAPTIVE_POLLING").
+		BoolVar(&cfg.Pulumi.Polling.Adaptive)
+
+	app.Flag("pulumi.deployment-stacks", "Collect the latest deployment of stacks matching these patterns (project/stack or org/project/stack globs, or re:<regex>).").
+		Envar("PULUMI_DEPLOYMENT_STACKS").
+		StringsVar(&cfg.Pulumi.DeploymentStacks)
+
+	app.Flag("pulumi.update-labels", "Add these labels from the update environment to update metrics (cli_version, exec_kind, ci_system).").
+		Envar("PULUMI_UPDATE_LABELS").
+		StringsVar(&cfg.Pulumi.UpdateLabels)
+
+	app.Flag("pulumi.include-stacks", "Only collect stacks matching these patterns (project/stack or org/project/stack globs, or re:<regex>).").
+		Envar("PULUMI_INCLUDE_STACKS").
+		StringsVar(&cfg.Pulumi.StackFilter.Include)
+
+	app.Flag("pulumi.exclude-stacks", "Skip stacks matching these patterns (project/stack or org/project/stack globs, or re:<regex>).").
+		Envar("PULUMI_EXCLUDE_STACKS").
+		StringsVar(&cfg.Pulumi.StackFilter.Exclude)
+
+	app.Flag("pulumi.stack-max-age", "Skip stacks whose last update is older than this (0 to disable).").
+		Default("0s").
+		Envar("PULUMI_STACK_MAX_AGE").
+		DurationVar(&cfg.Pulumi.StackFilter.MaxAge)
+
+	app.Flag("pulumi.violations.top-policies", "Report policy violations for the policies with the most violations (0 to disable).").
+		Default("20").
+		Envar("PULUMI_VIOLATIONS_TOP_POLICIES").
+		IntVar(&cfg.Pulumi.Violations.TopPolicies)
+
+	app.Flag("pulumi.violations.top-stacks", "Report policy violations for the stacks with the most violations (0 to disable).").
+		Default("20").
+		Envar("PULUMI_VIOLATIONS_TOP_STACKS").
+		IntVar(&cfg.Pulumi.Violations.TopStacks)
+
+	app.Flag("pulumi.violations.top-resource-types", "Report policy violations for the resource types with the most violations (0 to disable).").
+		Default("0").
+		Envar("PULUMI_VIOLATIONS_TOP_RESOURCE_TYPES").
+		IntVar(&cfg.Pulumi.Violations.TopResourceTypes)
+
+	for _, family := range Families {
+		registerCollectorFlags(app, family, cfg.Collectors.Family(family))
+	}
+
+	app.Flag("otlp.disabled", "Disable OTLP push, e.g. when only serving /metrics for Prometheus.").
+		Default("false").
+		Envar("PULUMI_EXPORTER_OTLP_DISABLED").
+		BoolVar(&cfg.Exporters.Disabled)
+
 	app.Flag("otlp.endpoint", "OTLP exporter endpoint.").
 		Default("localhost:4318").
 		Envar("OTEL_EXPORTER_OTLP_ENDPOINT").
@@ -88,9 +360,53 @@ func RegisterFlags(app *kingpin.Application) *Config {
 		Envar("OTEL_EXPORTER_OTLP_INSECURE").
 		BoolVar(&cfg.Exporters.Insecure)
 
+	app.Flag("prometheus.enabled", "Serve metrics for Prometheus scrapes at /metrics on the web listen address.").
+		Default("false").
+		Envar("PULUMI_EXPORTER_PROMETHEUS_ENABLED").
+		BoolVar(&cfg.Prometheus.Enabled)
+
+	app.Flag("checkpoint.store", "Checkpoint store for counted updates and deployments (memory or file).").
+		Default(checkpoint.StoreMemory).
+		Envar("PULUMI_EXPORTER_CHECKPOINT_STORE").
+		StringVar(&cfg.Checkpoint.Store)
+
+	app.Flag("checkpoint.path", "Path to the checkpoint file when using the file store.").
+		Envar("PULUMI_EXPORTER_CHECKPOINT_PATH").
+		StringVar(&cfg.Checkpoint.Path)
+
+	app.Flag("checkpoint.seed-policy", "How to count updates of stacks seen for the first time (history or latest).").
+		Default(SeedPolicyHistory).
+		Envar("PULUMI_EXPORTER_CHECKPOINT_SEED_POLICY").
+		StringVar(&cfg.Checkpoint.SeedPolicy)
+
+	app.Flag("web.ready-cycles", "Number of consecutive successful ListStacks cycles required before /readyz reports ready.").
+		Default("1").
+		Envar("PULUMI_EXPORTER_READY_CYCLES").
+		IntVar(&cfg.Web.ReadyCycles)
+
 	return cfg
 }
 
+// registerCollectorFlags registers the --collector.<family>.* flags of one metric family.
+func registerCollectorFlags(app *kingpin.Application, family string, cfg *CollectorConfig) {
+	env := "PULUMI_EXPORTER_COLLECTOR_" + strings.ToUpper(family)
+
+	app.Flag("collector."+family+".disabled", fmt.Sprintf("Disable collection of %s metrics.", family)).
+		Default("false").
+		Envar(env + "_DISABLED").
+		BoolVar(&cfg.Disabled)
+
+	app.Flag("collector."+family+".interval", fmt.Sprintf("Collection interval of %s metrics (0 uses --pulumi.collect-interval).", family)).
+		Default("0s").
+		Envar(env + "_INTERVAL").
+		DurationVar(&cfg.Interval)
+
+	app.Flag("collector."+family+".timeout", fmt.Sprintf("Collection timeout of %s metrics (0 uses 90%% of the interval, at least 10s).", family)).
+		Default("0s").
+		Envar(env + "_TIMEOUT").
+		DurationVar(&cfg.Timeout)
+}
+
 // LoadFile loads a YAML configuration file and overlays it onto the existing config.
 func (c *Config) LoadFile(path string) error {
 	data, err := os.ReadFile(path) //nolint:gosec // path comes from user-provided config flag
@@ -151,11 +467,123 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("max-concurrency must be between 1 and 100, got %d", c.Pulumi.MaxConcurrency)
 	}
 
-	switch c.Exporters.Protocol {
-	case protocolHTTPProtobuf, protocolGRPC:
+	if c.Pulumi.MaxUpdatePages < 0 {
+		return fmt.Errorf("max-update-pages must not be negative, got %d", c.Pulumi.MaxUpdatePages)
+	}
+
+	if c.Pulumi.MaxDeploymentPages < 0 {
+		return fmt.Errorf("max-deployment-pages must not be negative, got %d", c.Pulumi.MaxDeploymentPages)
+	}
+
+	if c.Pulumi.MaxViolationPages < 0 {
+		return fmt.Errorf("max-violation-pages must not be negative, got %d", c.Pulumi.MaxViolationPages)
+	}
+
+	if c.Pulumi.MaxRetries < 0 || c.Pulumi.RetryBudget < 0 {
+		return fmt.Errorf("max-retries and retry-budget must not be negative")
+	}
+
+	if c.Pulumi.RateLimit < 0 {
+		return fmt.Errorf("rate-limit must not be negative, got %g", c.Pulumi.RateLimit)
+	}
+
+	if c.Pulumi.StackFilter.MaxAge < 0 {
+		return fmt.Errorf("stack-max-age must not be negative, got %s", c.Pulumi.StackFilter.MaxAge)
+	}
+
+	if v := c.Pulumi.Violations; v.TopPolicies < 0 || v.TopStacks < 0 || v.TopResourceTypes < 0 {
+		return fmt.Errorf("violations top-policies, top-stacks and top-resource-types must not be negative")
+	}
+
+	for _, label := range c.Pulumi.UpdateLabels {
+		if !slices.Contains(UpdateLabels, label) {
+			return fmt.Errorf("unsupported update label: %q (must be one of %s)", label, strings.Join(UpdateLabels, ", "))
+		}
+	}
+
+	if c.Web.ReadyCycles < 0 {
+		return fmt.Errorf("ready-cycles must not be negative, got %d", c.Web.ReadyCycles)
+	}
+
+	if c.Exporters.Disabled && !c.Prometheus.Enabled {
+		return fmt.Errorf("at least one of OTLP push or the Prometheus endpoint must be enabled")
+	}
+
+	if !c.Exporters.Disabled {
+		switch c.Exporters.Protocol {
+		case protocolHTTPProtobuf, protocolGRPC:
+			// valid
+		default:
+			return fmt.Errorf("unsupported OTLP protocol: %q (must be http/protobuf or grpc)", c.Exporters.Protocol)
+		}
+	}
+
+	if err := c.validatePolling(); err != nil {
+		return err
+	}
+
+	for i, pack := range c.Pulumi.RequiredPolicyPacks {
+		if pack.Name == "" {
+			return fmt.Errorf("required policy pack %d: name is required", i+1)
+		}
+	}
+
+	if err := c.validateCollectors(); err != nil {
+		return err
+	}
+
+	return c.validateCheckpoint()
+}
+
+func (c *Config) validatePolling() error {
+	tiers := c.Pulumi.Polling.Tiers
+	names := make(map[string]struct{}, len(tiers))
+	for i, tier := range tiers {
+		if tier.Name == "" {
+			return fmt.Errorf("poll tier %d: name is required", i+1)
+		}
+		if _, ok := names[tier.Name]; ok {
+			return fmt.Errorf("poll tier %q: duplicate name", tier.Name)
+		}
+		names[tier.Name] = struct{}{}
+
+		if tier.MaxAge < 0 || tier.Interval < 0 {
+			return fmt.Errorf("poll tier %q: max-age and interval must not be negative", tier.Name)
+		}
+		if i > 0 && (tiers[i-1].MaxAge == 0 || tier.MaxAge != 0 && tier.MaxAge <= tiers[i-1].MaxAge) {
+			return fmt.Errorf("poll tier %q: tiers must be ordered by increasing max-age, with an unbounded max-age last", tier.Name)
+		}
+	}
+	return nil
+}
+
+func (c *Config) validateCollectors() error {
+	for _, family := range Families {
+		fc := c.Collectors.Family(family)
+		if fc.Interval < 0 || fc.Timeout < 0 {
+			return fmt.Errorf("collector %s: interval and timeout must not be negative", family)
+		}
+	}
+	return nil
+}
+
+func (c *Config) validateCheckpoint() error {
+	switch c.Checkpoint.Store {
+	case "", checkpoint.StoreMemory:
+		// valid
+	case checkpoint.StoreFile:
+		if c.Checkpoint.Path == "" {
+			return fmt.Errorf("checkpoint path is required for the file store")
+		}
+	default:
+		return fmt.Errorf("unsupported checkpoint store: %q (must be memory or file)", c.Checkpoint.Store)
+	}
+
+	switch c.Checkpoint.SeedPolicy {
+	case "", SeedPolicyHistory, SeedPolicyLatest:
 		// valid
 	default:
-		return fmt.Errorf("unsupported OTLP protocol: %q (must be http/protobuf or grpc)", c.Exporters.Protocol)
+		return fmt.Errorf("unsupported checkpoint seed policy: %q (must be history or latest)", c.Checkpoint.SeedPolicy)
 	}
 
 	return nil
@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/pulumi-labs/pulumi-exporter/internal/checkpoint"
 )
 
 const testOrgName = "org1"
@@ -41,6 +43,11 @@ func TestDefaults(t *testing.T) {
 	if cfg.Exporters.Insecure != false {
 		t.Errorf("expected insecure %v, got %v", false, cfg.Exporters.Insecure)
 	}
+
+	want := ViolationsConfig{TopPolicies: 20, TopStacks: 20}
+	if cfg.Pulumi.Violations != want {
+		t.Errorf("expected violations %+v, got %+v", want, cfg.Pulumi.Violations)
+	}
 }
 
 func TestLoadFile(t *testing.T) {
@@ -266,6 +273,18 @@ func TestMaxConcurrencyDefault(t *testing.T) {
 	if cfg.Pulumi.MaxConcurrency != 10 {
 		t.Errorf("expected max-concurrency 10, got %d", cfg.Pulumi.MaxConcurrency)
 	}
+
+	if cfg.Pulumi.MaxUpdatePages != 10 {
+		t.Errorf("expected max-update-pages 10, got %d", cfg.Pulumi.MaxUpdatePages)
+	}
+
+	if cfg.Pulumi.MaxRetries != 3 || cfg.Pulumi.RetryBudget != 30 {
+		t.Errorf("expected max-retries 3 and retry-budget 30, got %d and %d", cfg.Pulumi.MaxRetries, cfg.Pulumi.RetryBudget)
+	}
+
+	if cfg.Pulumi.RateLimit != 0 {
+		t.Errorf("expected rate-limit 0, got %g", cfg.Pulumi.RateLimit)
+	}
 }
 
 func TestMaxConcurrencyValidation(t *testing.T) {
@@ -309,3 +328,304 @@ func TestMaxConcurrencyValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckpointDefaults(t *testing.T) {
+	t.Parallel()
+
+	app := kingpin.New("test", "")
+	cfg := RegisterFlags(app)
+
+	_, err := app.Parse([]string{})
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+
+	if cfg.Checkpoint.Store != checkpoint.StoreMemory {
+		t.Errorf("expected checkpoint store %q, got %q", checkpoint.StoreMemory, cfg.Checkpoint.Store)
+	}
+
+	if cfg.Checkpoint.SeedPolicy != SeedPolicyHistory {
+		t.Errorf("expected seed policy %q, got %q", SeedPolicyHistory, cfg.Checkpoint.SeedPolicy)
+	}
+}
+
+func TestCheckpointValidation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		store      string
+		path       string
+		seedPolicy string
+		wantErr    bool
+	}{
+		{"memory", checkpoint.StoreMemory, "", SeedPolicyHistory, false},
+		{"file", checkpoint.StoreFile, "/var/lib/pulumi-exporter/checkpoint.json", SeedPolicyLatest, false},
+		{"file without path", checkpoint.StoreFile, "", SeedPolicyHistory, true},
+		{"unknown store", "redis", "", SeedPolicyHistory, true},
+		{"unknown seed policy", checkpoint.StoreMemory, "", "oldest", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := &Config{
+				Pulumi: PulumiConfig{
+					AccessToken:    "token",
+					Organizations:  []string{"org"},
+					MaxConcurrency: 10,
+				},
+				Exporters: ExportersConfig{
+					Protocol: protocolHTTPProtobuf,
+				},
+				Checkpoint: CheckpointConfig{
+					Store:      tt.store,
+					Path:       tt.path,
+					SeedPolicy: tt.seedPolicy,
+				},
+			}
+
+			err := cfg.Validate()
+			if tt.wantErr && err == nil {
+				t.Errorf("expected error, got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestReadyCyclesDefault(t *testing.T) {
+	t.Parallel()
+
+	app := kingpin.New("test", "")
+	cfg := RegisterFlags(app)
+
+	_, err := app.Parse([]string{})
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+
+	if cfg.Web.ReadyCycles != 1 {
+		t.Errorf("expected ready-cycles 1, got %d", cfg.Web.ReadyCycles)
+	}
+}
+
+func TestValidateExporters(t *testing.T) {
+	t.Parallel()
+
+	cfg := &Config{
+		Pulumi: PulumiConfig{
+			AccessToken:    "pul-token",
+			Organizations:  []string{"myorg"},
+			MaxConcurrency: 10,
+		},
+		Exporters: ExportersConfig{
+			Disabled: true,
+		},
+	}
+
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error with both OTLP and Prometheus disabled, got nil")
+	}
+
+	// The OTLP protocol is not checked when only Prometheus is enabled.
+	cfg.Prometheus.Enabled = true
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected no error for Prometheus only, got: %v", err)
+	}
+}
+
+func TestCollectorFlags(t *testing.T) {
+	t.Parallel()
+
+	app := kingpin.New("test", "")
+	cfg := RegisterFlags(app)
+
+	_, err := app.Parse([]string{
+		"--collector.members.disabled",
+		"--collector.stacks.interval=30s",
+		"--collector.stacks.timeout=25s",
+	})
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+
+	if !cfg.Collectors.Members.Disabled {
+		t.Error("expected members collector to be disabled")
+	}
+	if cfg.Collectors.Stacks.Interval != 30*time.Second || cfg.Collectors.Stacks.Timeout != 25*time.Second {
+		t.Errorf("expected stacks interval 30s and timeout 25s, got %+v", cfg.Collectors.Stacks)
+	}
+	if cfg.Collectors.Neo != (CollectorConfig{}) {
+		t.Errorf("expected neo collector defaults, got %+v", cfg.Collectors.Neo)
+	}
+}
+
+func TestLoadFileCollectors(t *testing.T) {
+	t.Parallel()
+
+	yamlContent := `
+collectors:
+  teams:
+    disabled: true
+  policies:
+    interval: 1h
+    timeout: 2m
+`
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(yamlContent), 0o600); err != nil {
+		t.Fatalf("failed to write temp config: %v", err)
+	}
+
+	cfg := &Config{}
+	if err := cfg.LoadFile(path); err != nil {
+		t.Fatalf("LoadFile error: %v", err)
+	}
+
+	if !cfg.Collectors.Teams.Disabled {
+		t.Error("expected teams collector to be disabled")
+	}
+	if cfg.Collectors.Policies.Interval != time.Hour || cfg.Collectors.Policies.Timeout != 2*time.Minute {
+		t.Errorf("expected policies interval 1h and timeout 2m, got %+v", cfg.Collectors.Policies)
+	}
+}
+
+func TestValidateCollectors(t *testing.T) {
+	t.Parallel()
+
+	cfg := &Config{
+		Pulumi: PulumiConfig{
+			AccessToken:    "pul-token",
+			Organizations:  []string{"myorg"},
+			MaxConcurrency: 10,
+		},
+		Exporters: ExportersConfig{Protocol: protocolHTTPProtobuf},
+	}
+	cfg.Collectors.Violations.Interval = -time.Second
+
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for a negative collector interval, got nil")
+	}
+}
+
+func TestValidatePolling(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		tiers   []PollTier
+		wantErr bool
+	}{
+		{"defaults", nil, false},
+		{"ordered", []PollTier{{Name: "hot", MaxAge: time.Hour}, {Name: "cold", Interval: time.Hour}}, false},
+		{"missing name", []PollTier{{MaxAge: time.Hour}}, true},
+		{"duplicate name", []PollTier{{Name: "a", MaxAge: time.Hour}, {Name: "a"}}, true},
+		{"negative interval", []PollTier{{Name: "a", Interval: -time.Second}}, true},
+		{"unordered", []PollTier{{Name: "a", MaxAge: time.Hour}, {Name: "b", MaxAge: time.Minute}}, true},
+		{"unbounded not last", []PollTier{{Name: "a"}, {Name: "b", MaxAge: time.Hour}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := &Config{
+				Pulumi: PulumiConfig{
+					AccessToken:    "pul-token",
+					Organizations:  []string{"myorg"},
+					MaxConcurrency: 10,
+					Polling:        PollingConfig{Adaptive: true, Tiers: tt.tiers},
+				},
+				Exporters: ExportersConfig{Protocol: protocolHTTPProtobuf},
+			}
+
+			err := cfg.Validate()
+			if tt.wantErr && err == nil {
+				t.Errorf("expected error, got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateUpdateLabels(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		labels  []string
+		wantErr bool
+	}{
+		{"none", nil, false},
+		{"supported", []string{UpdateLabelCLIVersion, UpdateLabelCISystem}, false},
+		{"unbounded", []string{"git_commit"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := &Config{
+				Pulumi: PulumiConfig{
+					AccessToken:    "pul-token",
+					Organizations:  []string{"myorg"},
+					MaxConcurrency: 10,
+					UpdateLabels:   tt.labels,
+				},
+				Exporters: ExportersConfig{Protocol: protocolHTTPProtobuf},
+			}
+
+			err := cfg.Validate()
+			if tt.wantErr && err == nil {
+				t.Errorf("expected error, got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateRequiredPolicyPacks(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		packs   []RequiredPolicyPack
+		wantErr bool
+	}{
+		{"none", nil, false},
+		{"scoped", []RequiredPolicyPack{{Name: "aws-security", Orgs: []string{"myorg"}, Stacks: []string{"*/prod"}}}, false},
+		{"missing name", []RequiredPolicyPack{{Stacks: []string{"*/prod"}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := &Config{
+				Pulumi: PulumiConfig{
+					AccessToken:         "pul-token",
+					Organizations:       []string{"myorg"},
+					MaxConcurrency:      10,
+					RequiredPolicyPacks: tt.packs,
+				},
+				Exporters: ExportersConfig{Protocol: protocolHTTPProtobuf},
+			}
+
+			err := cfg.Validate()
+			if tt.wantErr && err == nil {
+				t.Errorf("expected error, got nil")
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
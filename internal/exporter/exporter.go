@@ -1,12 +1,18 @@
-// Package exporter manages the OpenTelemetry MeterProvider for OTLP metric export.
+// Package exporter manages the OpenTelemetry MeterProvider for OTLP push and
+// Prometheus pull metric export.
 package exporter
 
 import (
 	"context"
 	"fmt"
+	"net/http"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/otlptranslator"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	otelprom "go.opentelemetry.io/otel/exporters/prometheus"
 	"go.opentelemetry.io/otel/metric"
 	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
@@ -28,13 +34,34 @@ type OTLPConfig struct {
 	Headers  map[string]string
 }
 
+// Option configures optional readers of an Exporter.
+type Option func(*options)
+
+type options struct {
+	prometheus bool
+}
+
+// WithPrometheus registers a Prometheus reader on the MeterProvider. Its
+// exposition handler is returned by Exporter.Handler.
+func WithPrometheus() Option {
+	return func(o *options) { o.prometheus = true }
+}
+
 // Exporter manages the OTel MeterProvider.
 type Exporter struct {
 	meterProvider *sdkmetric.MeterProvider
+	handler       http.Handler
 }
 
-// NewExporter creates a new Exporter with an OTLP metric exporter.
-func NewExporter(ctx context.Context, cfg *OTLPConfig, version string) (*Exporter, error) {
+// NewExporter creates a new Exporter. Metrics are pushed to the OTLP endpoint
+// in cfg unless cfg is nil, and exposed for Prometheus scrapes when
+// WithPrometheus is given.
+func NewExporter(ctx context.Context, cfg *OTLPConfig, version string, opts ...Option) (*Exporter, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	res, err := resource.New(ctx,
 		resource.WithAttributes(
 			semconv.ServiceName("pulumi-exporter"),
@@ -45,7 +72,43 @@ func NewExporter(ctx context.Context, cfg *OTLPConfig, version string) (*Exporte
 		return nil, fmt.Errorf("creating resource: %w", err)
 	}
 
-	var exp sdkmetric.Exporter
+	e := &Exporter{}
+	mpOpts := []sdkmetric.Option{sdkmetric.WithResource(res)}
+
+	if cfg != nil {
+		exp, err := newOTLPExporter(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		mpOpts = append(mpOpts, sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exp)))
+	}
+
+	if o.prometheus {
+		// Use a dedicated registry so /metrics only serves the exporter's own
+		// instruments, not the Go runtime collectors of the default registry.
+		reg := prometheus.NewRegistry()
+		reader, err := otelprom.New(
+			otelprom.WithRegisterer(reg),
+			otelprom.WithoutScopeInfo(),
+			otelprom.WithTranslationStrategy(otlptranslator.UnderscoreEscapingWithSuffixes),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("creating Prometheus exporter: %w", err)
+		}
+		mpOpts = append(mpOpts, sdkmetric.WithReader(reader))
+		e.handler = promhttp.HandlerFor(reg, promhttp.HandlerOpts{})
+	}
+
+	e.meterProvider = sdkmetric.NewMeterProvider(mpOpts...)
+
+	return e, nil
+}
+
+func newOTLPExporter(ctx context.Context, cfg *OTLPConfig) (sdkmetric.Exporter, error) {
+	var (
+		exp sdkmetric.Exporter
+		err error
+	)
 
 	switch cfg.Protocol {
 	case protocolHTTPProtobuf:
@@ -83,14 +146,7 @@ func NewExporter(ctx context.Context, cfg *OTLPConfig, version string) (*Exporte
 		return nil, fmt.Errorf("creating OTLP exporter: %w", err)
 	}
 
-	reader := sdkmetric.NewPeriodicReader(exp)
-
-	mp := sdkmetric.NewMeterProvider(
-		sdkmetric.WithResource(res),
-		sdkmetric.WithReader(reader),
-	)
-
-	return &Exporter{meterProvider: mp}, nil
+	return exp, nil
 }
 
 // Meter returns a named Meter from the MeterProvider.
@@ -98,6 +154,12 @@ func (e *Exporter) Meter() metric.Meter {
 	return e.meterProvider.Meter("pulumi-exporter")
 }
 
+// Handler returns the Prometheus exposition handler, or nil if the Exporter
+// was created without WithPrometheus.
+func (e *Exporter) Handler() http.Handler {
+	return e.handler
+}
+
 // Shutdown gracefully shuts down the MeterProvider, flushing any remaining metrics.
 func (e *Exporter) Shutdown(ctx context.Context) error {
 	return e.meterProvider.Shutdown(ctx)
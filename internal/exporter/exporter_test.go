@@ -2,6 +2,9 @@ package exporter
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -82,3 +85,52 @@ func TestShutdown(t *testing.T) {
 	// which is expected in tests. We just verify it does not panic.
 	_ = exp.Shutdown(ctx)
 }
+
+func TestNewExporterPrometheusOnly(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	exp, err := NewExporter(ctx, nil, "0.0.1-test", WithPrometheus())
+	if err != nil {
+		t.Fatalf("NewExporter() returned unexpected error: %v", err)
+	}
+	defer func() { _ = exp.Shutdown(ctx) }()
+
+	counter, err := exp.Meter().Int64Counter("pulumi_test_total")
+	if err != nil {
+		t.Fatalf("failed to create counter: %v", err)
+	}
+	counter.Add(ctx, 3)
+
+	rec := httptest.NewRecorder()
+	exp.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", http.NoBody))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "pulumi_test_total 3") {
+		t.Errorf("expected pulumi_test_total 3 in exposition, got:\n%s", body)
+	}
+}
+
+func TestHandlerNilWithoutPrometheus(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	cfg := &OTLPConfig{
+		Endpoint: "localhost:4318",
+		Protocol: protocolHTTPProtobuf,
+		Insecure: true,
+	}
+
+	exp, err := NewExporter(ctx, cfg, "0.0.1-test")
+	if err != nil {
+		t.Fatalf("NewExporter() returned unexpected error: %v", err)
+	}
+	defer func() { _ = exp.Shutdown(ctx) }()
+
+	if exp.Handler() != nil {
+		t.Error("expected nil Handler() without WithPrometheus")
+	}
+}
@@ -904,6 +904,21 @@ type AppListStacksResponse struct {
 	ContinuationToken *string `json:"continuationToken,omitempty"`
 }
 
+// AppPolicyGroup PolicyGroup details the stacks and the applied Policy Packs of an organization's Policy Group.
+type AppPolicyGroup struct {
+	// Name The unique name of the policy group.
+	Name string `json:"name"`
+
+	// IsOrgDefault Whether this is the organization's default policy group, applied to all stacks not in another group.
+	IsOrgDefault bool `json:"isOrgDefault"`
+
+	// Stacks The stacks assigned to this policy group.
+	Stacks []AppPulumiStackReference `json:"stacks"`
+
+	// AppliedPolicyPacks The policy packs enabled in this policy group.
+	AppliedPolicyPacks []AppPolicyPackMetadata `json:"appliedPolicyPacks"`
+}
+
 // AppPolicyGroupSummary PolicyGroupSummary details the name, applicable stacks and the applied Policy
 // Packs for an organization's Policy Group.
 type AppPolicyGroupSummary struct {
@@ -935,6 +950,24 @@ type AppPolicyGroupSummaryEntityType string
 // AppPolicyGroupSummaryMode The enforcement mode of the policy group.
 type AppPolicyGroupSummaryMode string
 
+// AppPolicyPackMetadata PolicyPackMetadata is the metadata of a Policy Pack applied to a Policy Group.
+type AppPolicyPackMetadata struct {
+	// Name The name
+	Name string `json:"name"`
+
+	// DisplayName The display name
+	DisplayName string `json:"displayName"`
+
+	// Version The version
+	Version int64 `json:"version"`
+
+	// VersionTag The version tag
+	VersionTag string `json:"versionTag"`
+
+	// Config The configuration of the policies of the Policy Pack
+	Config *map[string]interface{} `json:"config,omitempty"`
+}
+
 // AppPolicyPackWithVersions PolicyPackWithVersions details the specifics of a Policy Pack and all its available versions.
 type AppPolicyPackWithVersions struct {
 	// Name The name
@@ -950,6 +983,15 @@ type AppPolicyPackWithVersions struct {
 	VersionTags []string `json:"versionTags"`
 }
 
+// AppPulumiStackReference PulumiStackReference contains the StackName and ProjectName of the stack.
+type AppPulumiStackReference struct {
+	// Name The stack name
+	Name string `json:"name"`
+
+	// RoutingProject The project name
+	RoutingProject string `json:"routingProject"`
+}
+
 // AppStackLinks Represents app stack links.
 type AppStackLinks struct {
 	// Self The self link URL
@@ -2145,6 +2187,9 @@ type ClientInterface interface {
 	// ListPolicyGroups request
 	ListPolicyGroups(ctx context.Context, orgName string, reqEditors ...RequestEditorFn) (*http.Response, error)
 
+	// GetPolicyGroup request
+	GetPolicyGroup(ctx context.Context, orgName string, policyGroup string, reqEditors ...RequestEditorFn) (*http.Response, error)
+
 	// ListPolicyPacksOrgs request
 	ListPolicyPacksOrgs(ctx context.Context, orgName string, params *ListPolicyPacksOrgsParams, reqEditors ...RequestEditorFn) (*http.Response, error)
 
@@ -2233,6 +2278,18 @@ func (c *Client) ListPolicyGroups(ctx context.Context, orgName string, reqEditor
 	return c.Client.Do(req)
 }
 
+func (c *Client) GetPolicyGroup(ctx context.Context, orgName string, policyGroup string, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewGetPolicyGroupRequest(c.Server, orgName, policyGroup)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
 func (c *Client) ListPolicyPacksOrgs(ctx context.Context, orgName string, params *ListPolicyPacksOrgsParams, reqEditors ...RequestEditorFn) (*http.Response, error) {
 	req, err := NewListPolicyPacksOrgsRequest(c.Server, orgName, params)
 	if err != nil {
@@ -2676,6 +2733,47 @@ func NewListPolicyGroupsRequest(server string, orgName string) (*http.Request, e
 	return req, nil
 }
 
+// NewGetPolicyGroupRequest generates requests for GetPolicyGroup
+func NewGetPolicyGroupRequest(server string, orgName string, policyGroup string) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithOptions("simple", false, "orgName", orgName, runtime.StyleParamOptions{ParamLocation: runtime.ParamLocationPath, Type: "string", Format: ""})
+	if err != nil {
+		return nil, err
+	}
+
+	var pathParam1 string
+
+	pathParam1, err = runtime.StyleParamWithOptions("simple", false, "policyGroup", policyGroup, runtime.StyleParamOptions{ParamLocation: runtime.ParamLocationPath, Type: "string", Format: ""})
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/api/orgs/%s/policygroups/%s", pathParam0, pathParam1)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
 // NewListPolicyPacksOrgsRequest generates requests for ListPolicyPacksOrgs
 func NewListPolicyPacksOrgsRequest(server string, orgName string, params *ListPolicyPacksOrgsParams) (*http.Request, error) {
 	var err error
@@ -3390,6 +3488,9 @@ type ClientWithResponsesInterface interface {
 	// ListPolicyGroupsWithResponse request
 	ListPolicyGroupsWithResponse(ctx context.Context, orgName string, reqEditors ...RequestEditorFn) (*ListPolicyGroupsResp, error)
 
+	// GetPolicyGroupWithResponse request
+	GetPolicyGroupWithResponse(ctx context.Context, orgName string, policyGroup string, reqEditors ...RequestEditorFn) (*GetPolicyGroupResp, error)
+
 	// ListPolicyPacksOrgsWithResponse request
 	ListPolicyPacksOrgsWithResponse(ctx context.Context, orgName string, params *ListPolicyPacksOrgsParams, reqEditors ...RequestEditorFn) (*ListPolicyPacksOrgsResp, error)
 
@@ -3568,6 +3669,36 @@ func (r ListPolicyGroupsResp) ContentType() string {
 	return ""
 }
 
+type GetPolicyGroupResp struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *AppPolicyGroup
+}
+
+// Status returns HTTPResponse.Status
+func (r GetPolicyGroupResp) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r GetPolicyGroupResp) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+// ContentType is a convenience method to retrieve the Content-Type value from the HTTP response headers
+func (r GetPolicyGroupResp) ContentType() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Header.Get("Content-Type")
+	}
+	return ""
+}
+
 type ListPolicyPacksOrgsResp struct {
 	Body         []byte
 	HTTPResponse *http.Response
@@ -3883,6 +4014,15 @@ func (c *ClientWithResponses) ListPolicyGroupsWithResponse(ctx context.Context,
 	return ParseListPolicyGroupsResp(rsp)
 }
 
+// GetPolicyGroupWithResponse request returning *GetPolicyGroupResp
+func (c *ClientWithResponses) GetPolicyGroupWithResponse(ctx context.Context, orgName string, policyGroup string, reqEditors ...RequestEditorFn) (*GetPolicyGroupResp, error) {
+	rsp, err := c.GetPolicyGroup(ctx, orgName, policyGroup, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseGetPolicyGroupResp(rsp)
+}
+
 // ListPolicyPacksOrgsWithResponse request returning *ListPolicyPacksOrgsResp
 func (c *ClientWithResponses) ListPolicyPacksOrgsWithResponse(ctx context.Context, orgName string, params *ListPolicyPacksOrgsParams, reqEditors ...RequestEditorFn) (*ListPolicyPacksOrgsResp, error) {
 	rsp, err := c.ListPolicyPacksOrgs(ctx, orgName, params, reqEditors...)
@@ -4094,6 +4234,32 @@ func ParseListPolicyGroupsResp(rsp *http.Response) (*ListPolicyGroupsResp, error
 	return response, nil
 }
 
+// ParseGetPolicyGroupResp parses an HTTP response from a GetPolicyGroupWithResponse call
+func ParseGetPolicyGroupResp(rsp *http.Response) (*GetPolicyGroupResp, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &GetPolicyGroupResp{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest AppPolicyGroup
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
+
 // ParseListPolicyPacksOrgsResp parses an HTTP response from a ListPolicyPacksOrgsWithResponse call
 func ParseListPolicyPacksOrgsResp(rsp *http.Response) (*ListPolicyPacksOrgsResp, error) {
 	bodyBytes, err := io.ReadAll(rsp.Body)